@@ -1,8 +1,16 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -12,6 +20,7 @@ import (
 	"github.com/olamideolayemi/realestate-backend/configs"
 	"github.com/olamideolayemi/realestate-backend/internal/api"
 	"github.com/olamideolayemi/realestate-backend/internal/models"
+	"github.com/olamideolayemi/realestate-backend/internal/services"
 )
 
 func main() {
@@ -24,13 +33,12 @@ func main() {
 	}
 
 	// Auto migrate models (dev convenience)
-	if err := db.AutoMigrate(&models.User{}, &models.Property{}, &models.PropertyImage{}, &models.Booking{}); err != nil {
+	if err := db.AutoMigrate(&models.User{}, &models.EmailVerification{}, &models.Property{}, &models.PropertyImage{}, &models.Booking{}, &models.PropertyBlock{}, &models.Payment{}, &models.BookingCancellation{}, &models.BookingModification{}, &models.PropertyRate{}, &models.BookingLineItem{}, &models.FXRate{}, &models.Session{}, &models.SavedSearch{}, &models.UserFavorite{}, &models.Wishlist{}, &models.WishlistItem{}); err != nil {
 		log.Fatalf("auto migrate failed: %v", err)
 	}
 
-	// Create one router instance
-	r := gin.New()
-	r.Use(gin.Recovery(), cors.New(cors.Config{
+	router := gin.Default()
+	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:3000"},
 		AllowMethods:     []string{"GET", "POST", "PATCH", "PUT", "DELETE", "OPTIONS"},
 		AllowHeaders:     []string{"Authorization", "Content-Type"},
@@ -39,16 +47,76 @@ func main() {
 		MaxAge:           12 * time.Hour,
 	}))
 
-	router := gin.Default()
+	// Only proxies listed in TRUSTED_PROXIES may set X-Forwarded-For;
+	// otherwise clients could pick their own IP and dodge per-IP limits.
+	if err := router.SetTrustedProxies(envList("TRUSTED_PROXIES")); err != nil {
+		log.Fatalf("invalid TRUSTED_PROXIES: %v", err)
+	}
 
-	deps := api.NewDependencies(db)
+	deps, err := api.NewDependencies(db)
+	if err != nil {
+		log.Fatalf("failed to set up services: %v", err)
+	}
 	api.RegisterRoutes(router, deps)
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Background workers
+	var workers sync.WaitGroup
+	expiry := &services.BookingExpiryService{
+		DB:         db,
+		HoldWindow: envMinutes("BOOKING_HOLD_MINUTES", 30),
+		Interval:   envMinutes("BOOKING_SWEEP_INTERVAL_MINUTES", 1),
+	}
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		expiry.Run(ctx)
+	}()
+	deps.SavedSearches.Interval = envMinutes("SAVED_SEARCH_INTERVAL_MINUTES", 5)
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		deps.SavedSearches.Run(ctx)
+	}()
+
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
-	if err := router.Run(":" + port); err != nil {
-		log.Fatalf("server failed: %v", err)
+	srv := &http.Server{Addr: ":" + port, Handler: router}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("server failed: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("server shutdown: %v", err)
+	}
+	workers.Wait()
+}
+
+// envList reads a comma-separated list from the environment; nil when unset.
+func envList(key string) []string {
+	var out []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// envMinutes reads a whole number of minutes from the environment.
+func envMinutes(key string, def int) time.Duration {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil && v > 0 {
+		return time.Duration(v) * time.Minute
 	}
+	return time.Duration(def) * time.Minute
 }
@@ -1,35 +1,131 @@
 package api
 
 import (
+	"os"
+	"time"
+
 	"gorm.io/gorm"
 
 	"github.com/olamideolayemi/realestate-backend/internal/api/handlers"
+	"github.com/olamideolayemi/realestate-backend/internal/ratelimit"
+	"github.com/olamideolayemi/realestate-backend/internal/services"
 )
 
 type Dependencies struct {
 	DB *gorm.DB
+	// AuthLimiter caps auth requests per IP; OTPSendLimiter caps requests
+	// that send an email.
+	AuthLimiter    *ratelimit.Limiter
+	OTPSendLimiter *ratelimit.Limiter
+	// Blobs stores uploaded files; local stores are served by the API.
+	Blobs services.BlobStore
+	// SavedSearches also runs the alert emails in the background.
+	SavedSearches *services.SavedSearchService
 
-	AuthHandler     *handlers.AuthHandler
-	PropertyHandler *handlers.PropertyHandler
-	BookingHandler  *handlers.BookingHandler
-	HealthHandler   *handlers.HealthHandler
-	UsersHandler	*handlers.UsersHandler
+	AuthHandler          *handlers.AuthHandler
+	PropertyHandler      *handlers.PropertyHandler
+	BookingHandler       *handlers.BookingHandler
+	HealthHandler        *handlers.HealthHandler
+	UsersHandler         *handlers.UsersHandler
+	BlockHandler         *handlers.BlockHandler
+	CalendarHandler      *handlers.CalendarHandler
+	PaymentHandler       *handlers.PaymentHandler
+	AdminBookingHandler  *handlers.AdminBookingHandler
+	RateHandler          *handlers.RateHandler
+	FXHandler            *handlers.FXHandler
+	AgentPropertyHandler *handlers.AgentPropertyHandler
+	ListingReviewHandler *handlers.ListingReviewHandler
+	ImageHandler         *handlers.ImageHandler
+	AgentImageHandler    *handlers.ImageHandler
+	SavedSearchHandler   *handlers.SavedSearchHandler
+	FavoriteHandler      *handlers.FavoriteHandler
 }
 
-func NewDependencies(db *gorm.DB) *Dependencies {
+func NewDependencies(db *gorm.DB) (*Dependencies, error) {
 	deps := &Dependencies{DB: db}
 
-	auth := &handlers.AuthHandler{DB: db}
-	prop := &handlers.PropertyHandler{DB: db}
-	book := &handlers.BookingHandler{DB: db}
+	gateway, err := services.NewPaymentGatewayFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	limits := ratelimit.NewStoreFromEnv()
+	deps.AuthLimiter = &ratelimit.Limiter{Store: limits, Prefix: "rl:auth", Limit: 30, Window: time.Minute}
+	deps.OTPSendLimiter = &ratelimit.Limiter{Store: limits, Prefix: "rl:otp-send", Limit: 5, Window: 10 * time.Minute}
+
+	availability := &services.AvailabilityService{DB: db}
+	pricing := &services.PricingService{DB: db}
+	fx := &services.FXService{DB: db}
+	blobs := services.NewBlobStoreFromEnv()
+	images := &services.ImageService{DB: db, Blobs: blobs}
+	listings := &services.ListingService{DB: db, Images: images}
+	calendar := &services.CalendarService{DB: db}
+	payments := &services.PaymentService{
+		DB:           db,
+		Gateway:      gateway,
+		Availability: availability,
+		CallbackURL:  os.Getenv("PAYMENT_CALLBACK_URL"),
+	}
+	cancellations := &services.CancellationService{DB: db, Payments: payments}
+	bookings := &services.BookingService{DB: db, Availability: availability, Pricing: pricing}
+	favorites := &services.FavoriteService{DB: db}
+	savedSearches := &services.SavedSearchService{
+		DB:           db,
+		Availability: availability,
+		BaseURL:      os.Getenv("APP_BASE_URL"),
+	}
+
+	sessions := services.NewSessionServiceFromEnv(db)
+	auth := &handlers.AuthHandler{
+		DB:         db,
+		Sessions:   sessions,
+		Resets:     &services.PasswordResetService{DB: db, Sessions: sessions},
+		LoginGuard: services.NewAuthGuard(limits, "login"),
+		OTPGuard:   services.NewAuthGuard(limits, "otp"),
+	}
+	prop := &handlers.PropertyHandler{DB: db, Availability: availability, Pricing: pricing, FX: fx, Images: images, Favorites: favorites, Listings: listings}
+	book := &handlers.BookingHandler{
+		DB:            db,
+		Availability:  availability,
+		Pricing:       pricing,
+		Bookings:      bookings,
+		Cancellations: cancellations,
+		FX:            fx,
+	}
 	health := &handlers.HealthHandler{DB: db}
 	user := &handlers.UsersHandler{DB: db}
+	block := &handlers.BlockHandler{DB: db, Availability: availability}
+	cal := &handlers.CalendarHandler{DB: db, Calendar: calendar}
+	pay := &handlers.PaymentHandler{DB: db, Payments: payments}
+	adminBook := &handlers.AdminBookingHandler{DB: db, Bookings: bookings, Cancellations: cancellations}
+	rates := &handlers.RateHandler{DB: db}
+	fxRates := &handlers.FXHandler{DB: db, FX: fx}
+	agentProps := &handlers.AgentPropertyHandler{DB: db, Listings: listings}
+	reviews := &handlers.ListingReviewHandler{DB: db, Listings: listings}
+	imageHandler := &handlers.ImageHandler{DB: db, Images: images}
+	agentImages := &handlers.ImageHandler{DB: db, Images: images, Listings: listings, OwnerOnly: true}
+	searchHandler := &handlers.SavedSearchHandler{DB: db, Searches: savedSearches}
+	favoriteHandler := &handlers.FavoriteHandler{DB: db, Favorites: favorites}
 
 	deps.AuthHandler = auth
 	deps.PropertyHandler = prop
 	deps.BookingHandler = book
 	deps.HealthHandler = health
 	deps.UsersHandler = user
+	deps.BlockHandler = block
+	deps.CalendarHandler = cal
+	deps.PaymentHandler = pay
+	deps.AdminBookingHandler = adminBook
+	deps.RateHandler = rates
+	deps.FXHandler = fxRates
+	deps.AgentPropertyHandler = agentProps
+	deps.ListingReviewHandler = reviews
+	deps.ImageHandler = imageHandler
+	deps.AgentImageHandler = agentImages
+	deps.SavedSearchHandler = searchHandler
+	deps.FavoriteHandler = favoriteHandler
+	deps.Blobs = blobs
+	deps.SavedSearches = savedSearches
 
-	return deps
+	return deps, nil
 }
@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+	"github.com/olamideolayemi/realestate-backend/internal/services"
+	"github.com/olamideolayemi/realestate-backend/internal/utils"
+)
+
+// AdminBookingHandler serves the reservations desk.
+type AdminBookingHandler struct {
+	DB            *gorm.DB
+	Bookings      *services.BookingService
+	Cancellations *services.CancellationService
+}
+
+type AdminCancelRequest struct {
+	Reason string `json:"reason"`
+	// RefundPercent overrides the property's cancellation policy when set.
+	RefundPercent *int `json:"refund_percent" binding:"omitempty,min=0,max=100"`
+}
+
+type ConfirmBookingRequest struct {
+	PaymentRef string `json:"payment_ref"`
+}
+
+type ChangeDatesRequest struct {
+	Checkin  string `json:"checkin" binding:"required"`  // "YYYY-MM-DD"
+	Checkout string `json:"checkout" binding:"required"` // "YYYY-MM-DD"
+}
+
+// ListBookings lists all bookings, newest first. Filters: property_id,
+// user_id, status (comma separated) and from/to, which keep stays that
+// overlap the window.
+func (h *AdminBookingHandler) ListBookings(c *gin.Context) {
+	q := h.DB.Model(&models.Booking{})
+	if v := c.Query("property_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid property_id"})
+			return
+		}
+		q = q.Where("property_id = ?", id)
+	}
+	if v := c.Query("user_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+			return
+		}
+		q = q.Where("user_id = ?", id)
+	}
+	if v := c.Query("status"); v != "" {
+		statuses := strings.Split(v, ",")
+		for _, s := range statuses {
+			if !models.ValidBookingStatus(s) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "status must be one of pending, confirmed, cancelled, no_show"})
+				return
+			}
+		}
+		q = q.Where("status IN ?", statuses)
+	}
+	if v := c.Query("from"); v != "" {
+		from, err := services.ParseDate(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from date; use YYYY-MM-DD"})
+			return
+		}
+		q = q.Where("checkout > ?", from)
+	}
+	if v := c.Query("to"); v != "" {
+		to, err := services.ParseDate(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to date; use YYYY-MM-DD"})
+			return
+		}
+		q = q.Where("checkin < ?", to)
+	}
+
+	page, ok := paginate(c, utils.DefaultPageLimit, bookingsNewestFirst...)
+	if !ok {
+		return
+	}
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch bookings"})
+		return
+	}
+	page.Total = &total
+	var bookings []models.Booking
+	if err := q.Scopes(page.Scope).Find(&bookings).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch bookings"})
+		return
+	}
+	bookings = bookings[:page.Trim(len(bookings), func(i int) []interface{} {
+		return []interface{}{bookings[i].CreatedAt, bookings[i].ID}
+	})]
+	respondPage(c, page, gin.H{"bookings": bookings})
+}
+
+// GetBooking returns a booking with its payments and cancellation record.
+func (h *AdminBookingHandler) GetBooking(c *gin.Context) {
+	var booking models.Booking
+	if err := h.DB.Preload("LineItems").First(&booking, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "booking not found"})
+		return
+	}
+	var payments []models.Payment
+	if err := h.DB.Where("booking_id = ?", booking.ID).Order("created_at").Find(&payments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch payments"})
+		return
+	}
+	var cancellation *models.BookingCancellation
+	var rec models.BookingCancellation
+	if err := h.DB.Where("booking_id = ?", booking.ID).First(&rec).Error; err == nil {
+		cancellation = &rec
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"booking":      booking,
+		"payments":     payments,
+		"cancellation": cancellation,
+	})
+}
+
+func (h *AdminBookingHandler) ConfirmBooking(c *gin.Context) {
+	id, ok := bookingIDParam(c)
+	if !ok {
+		return
+	}
+	var req ConfirmBookingRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	booking, err := h.Bookings.Confirm(c.Request.Context(), id, req.PaymentRef)
+	if err != nil {
+		respondBookingChangeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"booking": booking})
+}
+
+func (h *AdminBookingHandler) CancelBooking(c *gin.Context) {
+	id, ok := bookingIDParam(c)
+	if !ok {
+		return
+	}
+	var req AdminCancelRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.Reason == "" {
+		req.Reason = "cancelled by staff"
+	}
+	opts := services.CancelOptions{By: staffID(c), Reason: req.Reason, RefundPercent: req.RefundPercent}
+
+	booking, cancellation, err := h.Cancellations.Cancel(c.Request.Context(), id, opts)
+	if err != nil {
+		respondCancelError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"booking": booking, "cancellation": cancellation})
+}
+
+func (h *AdminBookingHandler) MarkNoShow(c *gin.Context) {
+	id, ok := bookingIDParam(c)
+	if !ok {
+		return
+	}
+	booking, err := h.Bookings.MarkNoShow(c.Request.Context(), id)
+	if err != nil {
+		respondBookingChangeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"booking": booking})
+}
+
+func (h *AdminBookingHandler) ChangeDates(c *gin.Context) {
+	id, ok := bookingIDParam(c)
+	if !ok {
+		return
+	}
+	var req ChangeDatesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	checkin, err := services.ParseDate(req.Checkin)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid checkin date; use YYYY-MM-DD"})
+		return
+	}
+	checkout, err := services.ParseDate(req.Checkout)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid checkout date; use YYYY-MM-DD"})
+		return
+	}
+
+	change := services.BookingChange{Checkin: &checkin, Checkout: &checkout, By: staffID(c), Staff: true}
+	booking, mod, err := h.Bookings.Modify(c.Request.Context(), id, change)
+	if err != nil {
+		respondBookingChangeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"booking": booking, "modification": mod})
+}
+
+func bookingIDParam(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid booking id"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func respondBookingChangeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "booking not found"})
+	case errors.Is(err, services.ErrInvalidTransition),
+		errors.Is(err, services.ErrStayNotStarted),
+		errors.Is(err, services.ErrStayStarted),
+		errors.Is(err, services.ErrRateCurrency):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidGuests):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		if status, ok := availabilityStatus(err); ok {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update booking"})
+	}
+}
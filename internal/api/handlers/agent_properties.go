@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+	"github.com/olamideolayemi/realestate-backend/internal/services"
+	"github.com/olamideolayemi/realestate-backend/internal/utils"
+)
+
+// AgentPropertyHandler lets agents manage their own listings. Every lookup
+// is scoped to the signed-in owner, so other people's listings are 404s.
+type AgentPropertyHandler struct {
+	DB       *gorm.DB
+	Listings *services.ListingService
+}
+
+// ListMyProperties lists the agent's listings in every status, optionally
+// filtered by ?status=.
+func (h *AgentPropertyHandler) ListMyProperties(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	page, ok := paginate(c, utils.DefaultPageLimit,
+		utils.SortColumn{Column: "properties.created_at", Desc: true},
+		utils.SortColumn{Column: "properties.id", Desc: true})
+	if !ok {
+		return
+	}
+	q := h.DB.Scopes(services.OwnedBy(uid), page.Scope).Preload("Images", services.ImagesInOrder)
+	if status := c.Query("status"); status != "" {
+		if !models.ValidPropertyStatus(status) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "status must be one of draft, pending_review, published, archived"})
+			return
+		}
+		q = q.Where("status = ?", status)
+	}
+	var props []models.Property
+	if err := q.Find(&props).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch properties"})
+		return
+	}
+	props = props[:page.Trim(len(props), func(i int) []interface{} {
+		return []interface{}{props[i].CreatedAt, props[i].ID}
+	})]
+	respondPage(c, page, gin.H{"properties": props})
+}
+
+func (h *AgentPropertyHandler) GetMyProperty(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var p models.Property
+	if err := h.DB.Scopes(services.OwnedBy(uid)).Preload("Images", services.ImagesInOrder).First(&p, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "property not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"property": p})
+}
+
+// CreateMyProperty saves a new listing as a draft owned by the agent.
+func (h *AgentPropertyHandler) CreateMyProperty(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req CreatePropertyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	now := time.Now()
+	p := models.Property{
+		ID:        uuid.New(),
+		OwnerID:   &uid,
+		Status:    models.PropertyDraft,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := req.apply(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.DB.Create(&p).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create property"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"property": p})
+}
+
+// UpdateMyProperty edits the agent's listing. Editing a published listing
+// sends it back for review.
+func (h *AgentPropertyHandler) UpdateMyProperty(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req CreatePropertyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var p models.Property
+	if err := h.DB.Scopes(services.OwnedBy(uid)).First(&p, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "property not found"})
+		return
+	}
+	if err := req.apply(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.Listings.Resubmit(&p)
+	p.UpdatedAt = time.Now()
+	if err := h.DB.Save(&p).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update property"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"property": p})
+}
+
+func (h *AgentPropertyHandler) DeleteMyProperty(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "property not found"})
+		return
+	}
+	if err := h.Listings.Delete(c.Request.Context(), id, uid); err != nil {
+		respondListingError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Property deleted"})
+}
+
+// SubmitMyProperty puts a draft or archived listing in the review queue.
+func (h *AgentPropertyHandler) SubmitMyProperty(c *gin.Context) {
+	h.transition(c, models.PropertyPendingReview)
+}
+
+// WithdrawMyProperty takes a listing back to draft, e.g. out of review.
+func (h *AgentPropertyHandler) WithdrawMyProperty(c *gin.Context) {
+	h.transition(c, models.PropertyDraft)
+}
+
+// ArchiveMyProperty hides a listing without deleting it.
+func (h *AgentPropertyHandler) ArchiveMyProperty(c *gin.Context) {
+	h.transition(c, models.PropertyArchived)
+}
+
+func (h *AgentPropertyHandler) transition(c *gin.Context, to string) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "property not found"})
+		return
+	}
+	p, err := h.Listings.Transition(c.Request.Context(), id, uid, to)
+	if err != nil {
+		respondListingError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"property": p})
+}
+
+func respondListingError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "property not found"})
+	case errors.Is(err, services.ErrInvalidListingStatus), errors.Is(err, services.ErrListingHasBookings),
+		errors.Is(err, services.ErrNotInReview):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update listing"})
+	}
+}
@@ -1,9 +1,10 @@
 package handlers
 
 import (
+	"crypto/subtle"
+	"errors"
 	"fmt"
 	"net/http"
-	"os"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -11,13 +12,23 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/olamideolayemi/realestate-backend/internal/models"
+	"github.com/olamideolayemi/realestate-backend/internal/ratelimit"
+	"github.com/olamideolayemi/realestate-backend/internal/services"
 	"github.com/olamideolayemi/realestate-backend/internal/utils"
 )
 
 type AuthHandler struct {
-	DB *gorm.DB
+	DB       *gorm.DB
+	Sessions *services.SessionService
+	Resets   *services.PasswordResetService
+	// LoginGuard and OTPGuard lock out repeated wrong passwords and codes.
+	LoginGuard *services.AuthGuard
+	OTPGuard   *services.AuthGuard
 }
 
+// maxVerifyAttempts is how many wrong codes burn a verification code.
+const maxVerifyAttempts = 5
+
 type RegisterRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=8"`
@@ -29,6 +40,20 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Email       string `json:"email" binding:"required,email"`
+	Code        string `json:"code" binding:"required,len=6"`
+	NewPassword string `json:"new_password" binding:"required,min=8"`
+}
+
 func (h *AuthHandler) Register(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -57,7 +82,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		Email:        req.Email,
 		PasswordHash: hashed,
 		Name:         req.Name,
-		Role:         "user",
+		Role:         models.RoleUser,
 		IsVerified:   false,
 		ExpiresAt:    &expiry,
 	}
@@ -71,6 +96,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	code := utils.GenerateOTP()
 	verification := models.EmailVerification{
 		Email:     req.Email,
+		Purpose:   models.PurposeVerifyEmail,
 		Code:      code,
 		ExpiresAt: time.Now().Add(10 * time.Minute),
 	}
@@ -86,22 +112,8 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		fmt.Println("Mail error:", err)
 	}
 
-	// create token
-	// secret := os.Getenv("JWT_SECRET")
-	// if secret == "" {
-	// 	secret = "dev_secret"
-	// }
-
-	// token, err := utils.GenerateJWT(u.ID.String(), u.Role, secret, time.Hour*24*7)
-	// if err != nil {
-	// 	c.JSON(http.StatusInternalServerError, gin.H{"error": "could not generate token"})
-	// 	return
-	// }
-
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Registration successful. Please verify your email.",
-		// "user":  gin.H{"id": u.ID, "email": u.Email, "name": u.Name, "role": u.Role},
-		// "token": token,
 	})
 }
 
@@ -111,32 +123,36 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if lockedOut(c, h.LoginGuard, req.Email) {
+		return
+	}
 	var u models.User
 	if err := h.DB.Where("email = ?", req.Email).First(&u).Error; err != nil {
+		h.LoginGuard.Fail(c.Request.Context(), req.Email, c.ClientIP())
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
 		return
 	}
 	if !utils.CheckPasswordHash(req.Password, u.PasswordHash) {
+		h.LoginGuard.Fail(c.Request.Context(), req.Email, c.ClientIP())
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
 		return
 	}
+	h.LoginGuard.Succeed(c.Request.Context(), req.Email)
 	if !u.IsVerified {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Please verify your email before logging in"})
 		return
 	}
 
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "dev_secret"
-	}
-	token, err := utils.GenerateJWT(u.ID.String(), u.Role, secret, time.Hour*24*7)
+	tokens, err := h.Sessions.Issue(c.Request.Context(), &u, clientInfo(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not generate token"})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{
-		"user":  gin.H{"id": u.ID, "email": u.Email, "name": u.Name, "role": u.Role},
-		"token": token,
+		"user":          gin.H{"id": u.ID, "email": u.Email, "name": u.Name, "role": u.Role},
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
 	})
 }
 
@@ -150,11 +166,31 @@ func (h *AuthHandler) VerifyEmail(c *gin.Context) {
 		return
 	}
 
+	if lockedOut(c, h.OTPGuard, input.Email) {
+		return
+	}
+
 	var record models.EmailVerification
-	if err := h.DB.Where("email = ? AND code = ?", input.Email, input.Code).First(&record).Error; err != nil {
+	if err := h.DB.Where("email = ? AND purpose = ?", input.Email, models.PurposeVerifyEmail).First(&record).Error; err != nil {
+		h.OTPGuard.Fail(c.Request.Context(), input.Email, c.ClientIP())
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid verification code"})
 		return
 	}
+	if subtle.ConstantTimeCompare([]byte(record.Code), []byte(input.Code)) != 1 {
+		h.OTPGuard.Fail(c.Request.Context(), input.Email, c.ClientIP())
+		// count the miss; the guess that reaches the limit burns the code
+		res := h.DB.Model(&models.EmailVerification{}).
+			Where("id = ? AND attempts < ?", record.ID, maxVerifyAttempts-1).
+			Update("attempts", gorm.Expr("attempts + 1"))
+		if res.Error == nil && res.RowsAffected == 0 {
+			h.DB.Unscoped().Delete(&record)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Too many wrong codes. Please request a new one."})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid verification code"})
+		return
+	}
+	h.OTPGuard.Succeed(c.Request.Context(), input.Email)
 
 	if time.Now().After(record.ExpiresAt) {
 		h.DB.Delete(&record)
@@ -193,11 +229,7 @@ func (h *AuthHandler) VerifyEmail(c *gin.Context) {
 	}
 
 	// Generate token now
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "dev_secret"
-	}
-	token, err := utils.GenerateJWT(user.ID.String(), user.Role, secret, time.Hour*24*7)
+	tokens, err := h.Sessions.Issue(c.Request.Context(), &user, clientInfo(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not generate token"})
 		return
@@ -211,7 +243,9 @@ func (h *AuthHandler) VerifyEmail(c *gin.Context) {
 			"name":  user.Name,
 			"role":  user.Role,
 		},
-		"token": token,
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
 	})
 }
 
@@ -235,12 +269,13 @@ func (h *AuthHandler) ResendVerification(c *gin.Context) {
 	}
 
 	// Delete existing verification record if any
-	h.DB.Unscoped().Where("email = ?", input.Email).Delete(&models.EmailVerification{})
+	h.DB.Unscoped().Where("email = ? AND purpose = ?", input.Email, models.PurposeVerifyEmail).Delete(&models.EmailVerification{})
 
 	// Generate new OTP
 	code := utils.GenerateOTP()
 	verification := models.EmailVerification{
 		Email:     input.Email,
+		Purpose:   models.PurposeVerifyEmail,
 		Code:      code,
 		ExpiresAt: time.Now().Add(10 * time.Minute),
 	}
@@ -257,4 +292,166 @@ func (h *AuthHandler) ResendVerification(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Verification code resent. Please check your email."})
-}
\ No newline at end of file
+}
+
+// Refresh exchanges a refresh token for a new access and refresh token. The
+// old refresh token stops working; presenting it again revokes the session.
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	tokens, err := h.Sessions.Refresh(c.Request.Context(), req.RefreshToken, clientInfo(c))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidRefreshToken) || errors.Is(err, services.ErrRefreshTokenReused) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not refresh token"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
+	})
+}
+
+// Logout ends the session the access token belongs to.
+func (h *AuthHandler) Logout(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	family, err := uuid.Parse(c.GetString("currentSession"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no session"})
+		return
+	}
+	if err := h.Sessions.Revoke(c.Request.Context(), userID, family, services.RevokeLogout); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to log out"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
+
+// LogoutAll ends every session of the user, on all devices.
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	n, err := h.Sessions.RevokeAll(c.Request.Context(), userID, services.RevokeLogoutAll)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to log out"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out of all devices", "sessions_revoked": n})
+}
+
+// ListSessions lists the devices the user is signed in on.
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	sessions, err := h.Sessions.Active(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch sessions"})
+		return
+	}
+	current := c.GetString("currentSession")
+	out := make([]gin.H, 0, len(sessions))
+	for _, s := range sessions {
+		out = append(out, gin.H{
+			"id":                s.FamilyID,
+			"user_agent":        s.UserAgent,
+			"ip":                s.IP,
+			"last_refreshed_at": s.CreatedAt,
+			"expires_at":        s.ExpiresAt,
+			"current":           s.FamilyID.String() == current,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"sessions": out})
+}
+
+// RevokeSession logs one of the user's devices out.
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	family, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session id"})
+		return
+	}
+	if err := h.Sessions.Revoke(c.Request.Context(), userID, family, services.RevokeLogout); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "session not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke session"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
+// ForgotPassword emails a reset code. The answer is the same whether or not
+// the email has an account.
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.Resets.Request(c.Request.Context(), req.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start password reset"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "If an account exists for this email, a reset code has been sent."})
+}
+
+// ResetPassword sets a new password with the emailed code and signs the user
+// out of every device.
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if lockedOut(c, h.OTPGuard, req.Email) {
+		return
+	}
+	if err := h.Resets.Reset(c.Request.Context(), req.Email, req.Code, req.NewPassword); err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidResetCode):
+			h.OTPGuard.Fail(c.Request.Context(), req.Email, c.ClientIP())
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrTooManyAttempts):
+			h.OTPGuard.Fail(c.Request.Context(), req.Email, c.ClientIP())
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reset password"})
+		}
+		return
+	}
+	h.OTPGuard.Succeed(c.Request.Context(), req.Email)
+	c.JSON(http.StatusOK, gin.H{"message": "Password updated. Please log in with your new password."})
+}
+
+// lockedOut answers 429 when guard has locked out email or the client's IP.
+func lockedOut(c *gin.Context, guard *services.AuthGuard, email string) bool {
+	wait := guard.Locked(c.Request.Context(), email, c.ClientIP())
+	if wait <= 0 {
+		return false
+	}
+	c.Header("Retry-After", ratelimit.RetryAfterSeconds(wait))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many failed attempts; try again later"})
+	return true
+}
+
+func clientInfo(c *gin.Context) services.ClientInfo {
+	return services.ClientInfo{UserAgent: c.Request.UserAgent(), IP: c.ClientIP()}
+}
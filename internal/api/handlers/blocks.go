@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+	"github.com/olamideolayemi/realestate-backend/internal/services"
+	"github.com/olamideolayemi/realestate-backend/internal/utils"
+)
+
+type BlockHandler struct {
+	DB           *gorm.DB
+	Availability *services.AvailabilityService
+}
+
+type PropertyBlockRequest struct {
+	StartDate string `json:"start_date" binding:"required"` // "YYYY-MM-DD", first blocked night
+	EndDate   string `json:"end_date" binding:"required"`   // "YYYY-MM-DD", day the block ends
+	Reason    string `json:"reason"`                        // cleaning|owner_stay|maintenance|other
+	Note      string `json:"note"`
+}
+
+var blockReasons = map[string]bool{
+	"cleaning":    true,
+	"owner_stay":  true,
+	"maintenance": true,
+	"other":       true,
+}
+
+// parse validates the request and returns the blocked range and reason.
+func (r *PropertyBlockRequest) parse() (start, end time.Time, reason string, err error) {
+	if start, err = services.ParseDate(r.StartDate); err != nil {
+		return start, end, "", errors.New("invalid start_date; use YYYY-MM-DD")
+	}
+	if end, err = services.ParseDate(r.EndDate); err != nil {
+		return start, end, "", errors.New("invalid end_date; use YYYY-MM-DD")
+	}
+	if !end.After(start) {
+		return start, end, "", errors.New("end_date must be after start_date")
+	}
+	reason = r.Reason
+	if reason == "" {
+		reason = "maintenance"
+	}
+	if !blockReasons[reason] {
+		return start, end, "", errors.New("reason must be one of cleaning, owner_stay, maintenance, other")
+	}
+	return start, end, reason, nil
+}
+
+func (h *BlockHandler) ListBlocks(c *gin.Context) {
+	q := h.DB.Where("property_id = ?", c.Param("id"))
+	if v := c.Query("from"); v != "" {
+		from, err := services.ParseDate(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from date; use YYYY-MM-DD"})
+			return
+		}
+		q = q.Where("end_date > ?", from)
+	}
+	if v := c.Query("to"); v != "" {
+		to, err := services.ParseDate(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to date; use YYYY-MM-DD"})
+			return
+		}
+		q = q.Where("start_date < ?", to)
+	}
+
+	page, ok := paginate(c, utils.MaxPageLimit,
+		utils.SortColumn{Column: "start_date"}, utils.SortColumn{Column: "id"})
+	if !ok {
+		return
+	}
+	var blocks []models.PropertyBlock
+	if err := q.Scopes(page.Scope).Find(&blocks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch blocks"})
+		return
+	}
+	blocks = blocks[:page.Trim(len(blocks), func(i int) []interface{} {
+		return []interface{}{blocks[i].StartDate, blocks[i].ID}
+	})]
+	respondPage(c, page, gin.H{"blocks": blocks})
+}
+
+func (h *BlockHandler) CreateBlock(c *gin.Context) {
+	var req PropertyBlockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	start, end, reason, err := req.parse()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var prop models.Property
+	if err := h.DB.First(&prop, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "property not found"})
+		return
+	}
+
+	block := models.PropertyBlock{
+		ID:         uuid.New(),
+		PropertyID: prop.ID,
+		StartDate:  start,
+		EndDate:    end,
+		Reason:     reason,
+		Note:       req.Note,
+		Source:     "manual",
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	if uid, ok := c.Get("currentUser"); ok {
+		if id, ok := uid.(uuid.UUID); ok {
+			block.CreatedBy = &id
+		}
+	}
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := h.checkBookings(tx, prop.ID, start, end); err != nil {
+			return err
+		}
+		return tx.Create(&block).Error
+	})
+	if err != nil {
+		respondBlockError(c, err, "failed to create block")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"block": block})
+}
+
+func (h *BlockHandler) UpdateBlock(c *gin.Context) {
+	var req PropertyBlockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	start, end, reason, err := req.parse()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var block models.PropertyBlock
+	if err := h.DB.First(&block, "id = ? AND property_id = ?", c.Param("blockId"), c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "block not found"})
+		return
+	}
+	block.StartDate = start
+	block.EndDate = end
+	block.Reason = reason
+	block.Note = req.Note
+	block.UpdatedAt = time.Now()
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := h.checkBookings(tx, block.PropertyID, start, end); err != nil {
+			return err
+		}
+		return tx.Save(&block).Error
+	})
+	if err != nil {
+		respondBlockError(c, err, "failed to update block")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"block": block})
+}
+
+func (h *BlockHandler) DeleteBlock(c *gin.Context) {
+	res := h.DB.Delete(&models.PropertyBlock{}, "id = ? AND property_id = ?", c.Param("blockId"), c.Param("id"))
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete block"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "block not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Block removed"})
+}
+
+// checkBookings refuses to block nights that guests already hold.
+func (h *BlockHandler) checkBookings(tx *gorm.DB, propertyID uuid.UUID, start, end time.Time) error {
+	bookings, _, err := h.Availability.Conflicts(tx, propertyID, start, end)
+	if err != nil {
+		return err
+	}
+	if bookings > 0 {
+		return services.ErrNotAvailable
+	}
+	return nil
+}
+
+func respondBlockError(c *gin.Context, err error, fallback string) {
+	if errors.Is(err, services.ErrNotAvailable) {
+		c.JSON(http.StatusConflict, gin.H{"error": "dates overlap existing bookings"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+}
@@ -1,25 +1,37 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/olamideolayemi/realestate-backend/internal/models"
+	"github.com/olamideolayemi/realestate-backend/internal/services"
+	"github.com/olamideolayemi/realestate-backend/internal/utils"
 )
 
 type BookingHandler struct {
-	DB *gorm.DB
+	DB            *gorm.DB
+	Availability  *services.AvailabilityService
+	Pricing       *services.PricingService
+	Bookings      *services.BookingService
+	Cancellations *services.CancellationService
+	FX            *services.FXService
 }
 
 type CreateBookingRequest struct {
 	PropertyID string `json:"property_id" binding:"required,uuid"`
 	Checkin    string `json:"checkin" binding:"required"`  // "YYYY-MM-DD"
 	Checkout   string `json:"checkout" binding:"required"` // "YYYY-MM-DD"
-	Guests     int    `json:"guests" binding:"required"`
+	Guests     int    `json:"guests" binding:"required,min=1"`
+	// DisplayCurrency snapshots the rate the guest saw; the booking is still
+	// charged in the property's currency.
+	DisplayCurrency string `json:"display_currency"`
 }
 
 func (h *BookingHandler) CreateBooking(c *gin.Context) {
@@ -29,6 +41,16 @@ func (h *BookingHandler) CreateBooking(c *gin.Context) {
 		return
 	}
 
+	display := ""
+	if req.DisplayCurrency != "" {
+		cur, err := services.NormalizeCurrency(req.DisplayCurrency)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid display_currency: " + err.Error()})
+			return
+		}
+		display = cur
+	}
+
 	pid, err := uuid.Parse(req.PropertyID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid property id"})
@@ -36,40 +58,16 @@ func (h *BookingHandler) CreateBooking(c *gin.Context) {
 	}
 
 	// parse dates
-	layout := "2006-01-02"
-	checkin, err := time.Parse(layout, req.Checkin)
+	checkin, err := services.ParseDate(req.Checkin)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid checkin date; use YYYY-MM-DD"})
 		return
 	}
-	checkout, err := time.Parse(layout, req.Checkout)
+	checkout, err := services.ParseDate(req.Checkout)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid checkout date; use YYYY-MM-DD"})
 		return
 	}
-	if !checkout.After(checkin) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "checkout must be after checkin"})
-		return
-	}
-
-	// find property
-	var prop models.Property
-	if err := h.DB.First(&prop, "id = ?", pid).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "property not found"})
-		return
-	}
-	if prop.Category != "shortlet" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "property not bookable as shortlet"})
-		return
-	}
-
-	// compute nights
-	nights := int(checkout.Sub(checkin).Hours() / 24)
-	if nights <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date range"})
-		return
-	}
-	total := float64(nights) * prop.Price
 
 	// Get user id from context (set by middleware)
 	userIDval, exists := c.Get("currentUser")
@@ -92,18 +90,26 @@ func (h *BookingHandler) CreateBooking(c *gin.Context) {
 		}
 	}()
 
-	var conflictCount int64
-	if err := tx.Model(&models.Booking{}).
-		Where("property_id = ? AND status IN ? AND NOT (checkout <= ? OR checkin >= ?)",
-			prop.ID, []string{"confirmed", "pending"}, checkin, checkout).
-		Count(&conflictCount).Error; err != nil {
+	// find and lock the property, so concurrent bookings and modifications
+	// of its nights wait for this one
+	var prop models.Property
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Scopes(services.Published).
+		First(&prop, "id = ?", pid).Error; err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed availability check"})
+		c.JSON(http.StatusNotFound, gin.H{"error": "property not found"})
 		return
 	}
-	if conflictCount > 0 {
+
+	nights, err := h.Availability.CheckAvailability(tx, &prop, checkin, checkout)
+	if err != nil {
+		tx.Rollback()
+		respondAvailabilityError(c, err)
+		return
+	}
+	quote, err := h.Pricing.Quote(tx, &prop, checkin, checkout, req.Guests)
+	if err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusConflict, gin.H{"error": "property not available for selected dates"})
+		respondPricingError(c, err, "failed to price booking")
 		return
 	}
 
@@ -115,11 +121,20 @@ func (h *BookingHandler) CreateBooking(c *gin.Context) {
 		Checkout:    checkout,
 		Nights:      nights,
 		Guests:      req.Guests,
-		TotalAmount: total,
+		TotalAmount: quote.Total,
+		Currency:    quote.Currency,
 		Status:      "pending",
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
+	booking.LineItems = quote.LineItems(booking.ID)
+	if display != "" && display != booking.Currency {
+		if err := h.FX.SnapshotBooking(tx, &booking, display); err != nil {
+			tx.Rollback()
+			respondFXError(c, err)
+			return
+		}
+	}
 
 	if err := tx.Create(&booking).Error; err != nil {
 		tx.Rollback()
@@ -135,6 +150,13 @@ func (h *BookingHandler) CreateBooking(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{"booking": booking})
 }
 
+// bookingsNewestFirst is the order of booking lists.
+var bookingsNewestFirst = []utils.SortColumn{
+	{Column: "bookings.created_at", Desc: true},
+	{Column: "bookings.id", Desc: true},
+}
+
+// ListUserBookings lists the signed-in user's bookings, newest first.
 func (h *BookingHandler) ListUserBookings(c *gin.Context) {
 	userIDval, exists := c.Get("currentUser")
 	if !exists {
@@ -146,10 +168,172 @@ func (h *BookingHandler) ListUserBookings(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid user in context"})
 		return
 	}
+	page, ok := paginate(c, utils.DefaultPageLimit, bookingsNewestFirst...)
+	if !ok {
+		return
+	}
 	var bookings []models.Booking
-	if err := h.DB.Where("user_id = ?", userID).Find(&bookings).Error; err != nil {
+	if err := h.DB.Preload("LineItems").Where("user_id = ?", userID).Scopes(page.Scope).Find(&bookings).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch bookings"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"bookings": bookings})
+	bookings = bookings[:page.Trim(len(bookings), func(i int) []interface{} {
+		return []interface{}{bookings[i].CreatedAt, bookings[i].ID}
+	})]
+	respondPage(c, page, gin.H{"bookings": bookings})
+}
+
+type UpdateBookingRequest struct {
+	Checkin  *string `json:"checkin"`  // "YYYY-MM-DD"
+	Checkout *string `json:"checkout"` // "YYYY-MM-DD"
+	Guests   *int    `json:"guests" binding:"omitempty,min=1"`
+}
+
+// UpdateBooking lets a guest move their booking or change the party size.
+// The response carries the price difference owed or refundable.
+func (h *BookingHandler) UpdateBooking(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req UpdateBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Checkin == nil && req.Checkout == nil && req.Guests == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "nothing to change"})
+		return
+	}
+
+	change := services.BookingChange{Guests: req.Guests, By: &userID}
+	if req.Checkin != nil {
+		d, err := services.ParseDate(*req.Checkin)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid checkin date; use YYYY-MM-DD"})
+			return
+		}
+		change.Checkin = &d
+	}
+	if req.Checkout != nil {
+		d, err := services.ParseDate(*req.Checkout)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid checkout date; use YYYY-MM-DD"})
+			return
+		}
+		change.Checkout = &d
+	}
+
+	var booking models.Booking
+	if err := h.DB.First(&booking, "id = ? AND user_id = ?", c.Param("id"), userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "booking not found"})
+		return
+	}
+
+	updated, mod, err := h.Bookings.Modify(c.Request.Context(), booking.ID, change)
+	if err != nil {
+		respondBookingChangeError(c, err)
+		return
+	}
+	owed := models.NewMoney(0, mod.Currency)
+	refundable := owed
+	switch mod.Settlement {
+	case "owed":
+		owed = mod.Difference
+	case "refundable":
+		refundable = mod.Difference.Neg()
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"booking":      updated,
+		"modification": mod,
+		"amount_owed":  owed,
+		"refundable":   refundable,
+	})
+}
+
+type CancelBookingRequest struct {
+	Reason string `json:"reason"`
+}
+
+// CancelBooking lets a guest cancel their own booking, refunding per the
+// property's cancellation policy.
+func (h *BookingHandler) CancelBooking(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req CancelBookingRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if req.Reason == "" {
+		req.Reason = "cancelled by guest"
+	}
+
+	var booking models.Booking
+	if err := h.DB.First(&booking, "id = ? AND user_id = ?", c.Param("id"), userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "booking not found"})
+		return
+	}
+
+	updated, cancellation, err := h.Cancellations.Cancel(c.Request.Context(), booking.ID, services.CancelOptions{
+		By:     &userID,
+		Reason: req.Reason,
+	})
+	if err != nil {
+		respondCancelError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"booking": updated, "cancellation": cancellation})
+}
+
+func respondCancelError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrNotCancellable), errors.Is(err, services.ErrStayStarted):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "booking not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to cancel booking"})
+	}
+}
+
+// respondPricingError reports a quote that could not be priced; fallback is
+// the message for unexpected errors.
+func respondPricingError(c *gin.Context, err error, fallback string) {
+	if errors.Is(err, services.ErrRateCurrency) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+}
+
+// respondAvailabilityError maps availability rule violations to HTTP responses.
+func respondAvailabilityError(c *gin.Context, err error) {
+	if status, ok := availabilityStatus(err); ok {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "failed availability check"})
+}
+
+// availabilityStatus returns the HTTP status for an availability rule
+// violation, or false if err is not one.
+func availabilityStatus(err error) (int, bool) {
+	switch {
+	case errors.Is(err, services.ErrNotAvailable):
+		return http.StatusConflict, true
+	case errors.Is(err, services.ErrInvalidRange),
+		errors.Is(err, services.ErrRangeTooLong),
+		errors.Is(err, services.ErrNotBookable),
+		errors.Is(err, services.ErrStayTooShort),
+		errors.Is(err, services.ErrStayTooLong),
+		errors.Is(err, services.ErrStayOverCap):
+		return http.StatusBadRequest, true
+	}
+	return 0, false
 }
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+	"github.com/olamideolayemi/realestate-backend/internal/services"
+	"github.com/olamideolayemi/realestate-backend/internal/utils"
+)
+
+// maxICalUpload bounds the size of an imported calendar.
+const maxICalUpload = 2 << 20
+
+type CalendarHandler struct {
+	DB       *gorm.DB
+	Calendar *services.CalendarService
+}
+
+// ExportCalendar serves a property's availability as an .ics feed. The feed is
+// public but must carry the token issued by FeedURL.
+func (h *CalendarHandler) ExportCalendar(c *gin.Context) {
+	pid, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "property not found"})
+		return
+	}
+	if err := h.Calendar.VerifyFeedToken(pid, c.Query("token")); err != nil {
+		respondFeedTokenError(c, err)
+		return
+	}
+	var prop models.Property
+	if err := h.DB.First(&prop, "id = ?", pid).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "property not found"})
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := h.Calendar.Export(&buf, &prop); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build calendar"})
+		return
+	}
+	c.Header("Content-Disposition", `inline; filename="`+prop.ID.String()+`.ics"`)
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", buf.Bytes())
+}
+
+// FeedURL returns the signed feed URL to register with other channels.
+func (h *CalendarHandler) FeedURL(c *gin.Context) {
+	var prop models.Property
+	if err := h.DB.First(&prop, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "property not found"})
+		return
+	}
+	token, err := h.Calendar.FeedToken(prop.ID)
+	if err != nil {
+		respondFeedTokenError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"url":   "/api/v1/properties/" + prop.ID.String() + "/calendar.ics?token=" + token,
+		"token": token,
+	})
+}
+
+// ImportCalendar ingests an .ics upload (multipart field "file") or raw
+// text/calendar body and turns its events into blocks tagged with ?source=.
+func (h *CalendarHandler) ImportCalendar(c *gin.Context) {
+	var prop models.Property
+	if err := h.DB.First(&prop, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "property not found"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxICalUpload)
+	var r io.Reader
+	source := c.Query("source")
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+			return
+		}
+		f, err := fh.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "could not read file"})
+			return
+		}
+		defer f.Close()
+		r = f
+		if source == "" {
+			source = c.PostForm("source")
+		}
+	} else {
+		r = c.Request.Body
+	}
+
+	var createdBy *uuid.UUID
+	if uid, ok := c.Get("currentUser"); ok {
+		if id, ok := uid.(uuid.UUID); ok {
+			createdBy = &id
+		}
+	}
+
+	res, err := h.Calendar.Import(r, prop.ID, source, createdBy)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.Is(err, utils.ErrInvalidICal):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.As(err, &tooLarge):
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "calendar file too large"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to import calendar"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": res})
+}
+
+// respondFeedTokenError maps feed token errors to responses.
+func respondFeedTokenError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrNoFeedSecret) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "calendar feeds are not configured"})
+		return
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,352 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+	"github.com/olamideolayemi/realestate-backend/internal/services"
+	"github.com/olamideolayemi/realestate-backend/internal/utils"
+)
+
+// FavoriteHandler manages the signed-in user's favorites and wishlists, and
+// the public view of shared wishlists.
+type FavoriteHandler struct {
+	DB        *gorm.DB
+	Favorites *services.FavoriteService
+}
+
+type WishlistRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// AddFavorite favorites a published property. It answers 201 the first
+// time and 200 when the property was already a favorite.
+func (h *FavoriteHandler) AddFavorite(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	pid, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "property not found"})
+		return
+	}
+	created, err := h.Favorites.Favorite(c.Request.Context(), uid, pid)
+	if err != nil {
+		respondFavoriteError(c, err)
+		return
+	}
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, gin.H{"property_id": pid, "is_favorite": true})
+}
+
+func (h *FavoriteHandler) RemoveFavorite(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	pid, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "property not found"})
+		return
+	}
+	if err := h.Favorites.Unfavorite(c.Request.Context(), uid, pid); err != nil {
+		respondFavoriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"property_id": pid, "is_favorite": false})
+}
+
+// ListFavorites lists the user's favorite properties with their images,
+// most recently favorited first. Listings no longer published are left out.
+func (h *FavoriteHandler) ListFavorites(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	page, ok := paginate(c, utils.DefaultPageLimit, services.FavoriteOrder...)
+	if !ok {
+		return
+	}
+	favs, props, err := h.Favorites.Favorites(c.Request.Context(), uid, page.Scope)
+	if err != nil {
+		respondFavoriteError(c, err)
+		return
+	}
+	favs = favs[:page.Trim(len(favs), func(i int) []interface{} {
+		return []interface{}{favs[i].CreatedAt, favs[i].ID}
+	})]
+	ids := make([]uuid.UUID, len(favs))
+	for i := range favs {
+		ids[i] = favs[i].PropertyID
+	}
+	views, err := propertyViews(onPage(props, ids), nil)
+	if err != nil {
+		respondFXError(c, err)
+		return
+	}
+	yes := true
+	for i := range views {
+		views[i].IsFavorite = &yes
+	}
+	hideReviewFields(c, views)
+	respondPage(c, page, gin.H{"properties": views})
+}
+
+// onPage keeps the props whose ids are in ids, dropping the paginator's
+// look-ahead row.
+func onPage(props []models.Property, ids []uuid.UUID) []models.Property {
+	keep := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		keep[id] = true
+	}
+	kept := props[:0]
+	for _, p := range props {
+		if keep[p.ID] {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+// ListWishlists lists the user's wishlists, newest first.
+func (h *FavoriteHandler) ListWishlists(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	page, ok := paginate(c, utils.DefaultPageLimit,
+		utils.SortColumn{Column: "created_at", Desc: true},
+		utils.SortColumn{Column: "id", Desc: true})
+	if !ok {
+		return
+	}
+	var lists []models.Wishlist
+	if err := h.DB.Where("user_id = ?", uid).Scopes(page.Scope).Find(&lists).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch wishlists"})
+		return
+	}
+	lists = lists[:page.Trim(len(lists), func(i int) []interface{} {
+		return []interface{}{lists[i].CreatedAt, lists[i].ID}
+	})]
+	respondPage(c, page, gin.H{"wishlists": lists})
+}
+
+func (h *FavoriteHandler) CreateWishlist(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req WishlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+	w, err := h.Favorites.CreateWishlist(c.Request.Context(), uid, name)
+	if err != nil {
+		respondFavoriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"wishlist": w})
+}
+
+// GetWishlist returns one of the user's wishlists with a page of its
+// properties, most recently added first.
+func (h *FavoriteHandler) GetWishlist(c *gin.Context) {
+	w, ok := h.ownWishlist(c)
+	if !ok {
+		return
+	}
+	h.respondWishlist(c, w, w)
+}
+
+// GetSharedWishlist shows a wishlist to anyone holding its share link.
+func (h *FavoriteHandler) GetSharedWishlist(c *gin.Context) {
+	w, err := h.Favorites.SharedWishlist(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "wishlist not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch wishlist"})
+		return
+	}
+	// visitors see the list, not who owns it or its token
+	h.respondWishlist(c, w, gin.H{"id": w.ID, "name": w.Name, "updated_at": w.UpdatedAt})
+}
+
+// respondWishlist writes wishlist as the "wishlist" field, with a page of
+// w's properties.
+func (h *FavoriteHandler) respondWishlist(c *gin.Context, w *models.Wishlist, wishlist interface{}) {
+	page, ok := paginate(c, utils.DefaultPageLimit, services.WishlistItemOrder...)
+	if !ok {
+		return
+	}
+	items, props, err := h.Favorites.WishlistProperties(c.Request.Context(), w.ID, page.Scope)
+	if err != nil {
+		respondFavoriteError(c, err)
+		return
+	}
+	items = items[:page.Trim(len(items), func(i int) []interface{} {
+		return []interface{}{items[i].CreatedAt, items[i].PropertyID}
+	})]
+	ids := make([]uuid.UUID, len(items))
+	for i := range items {
+		ids[i] = items[i].PropertyID
+	}
+	views, err := propertyViews(onPage(props, ids), nil)
+	if err != nil {
+		respondFXError(c, err)
+		return
+	}
+	hideReviewFields(c, views)
+	respondPage(c, page, gin.H{"wishlist": wishlist, "properties": views})
+}
+
+// RenameWishlist changes a wishlist's name.
+func (h *FavoriteHandler) RenameWishlist(c *gin.Context) {
+	w, ok := h.ownWishlist(c)
+	if !ok {
+		return
+	}
+	var req WishlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+	if err := h.DB.Model(w).Update("name", name).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update wishlist"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"wishlist": w})
+}
+
+func (h *FavoriteHandler) DeleteWishlist(c *gin.Context) {
+	w, ok := h.ownWishlist(c)
+	if !ok {
+		return
+	}
+	if err := h.Favorites.DeleteWishlist(c.Request.Context(), w); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete wishlist"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Wishlist deleted"})
+}
+
+func (h *FavoriteHandler) AddToWishlist(c *gin.Context) {
+	w, ok := h.ownWishlist(c)
+	if !ok {
+		return
+	}
+	pid, err := uuid.Parse(c.Param("propertyId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "property not found"})
+		return
+	}
+	if err := h.Favorites.AddToWishlist(c.Request.Context(), w, pid); err != nil {
+		respondFavoriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Added to " + w.Name})
+}
+
+func (h *FavoriteHandler) RemoveFromWishlist(c *gin.Context) {
+	w, ok := h.ownWishlist(c)
+	if !ok {
+		return
+	}
+	pid, err := uuid.Parse(c.Param("propertyId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "property not found"})
+		return
+	}
+	if err := h.Favorites.RemoveFromWishlist(c.Request.Context(), w, pid); err != nil {
+		respondFavoriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Removed from " + w.Name})
+}
+
+// ShareWishlist returns the wishlist's share link, creating it if needed.
+func (h *FavoriteHandler) ShareWishlist(c *gin.Context) {
+	w, ok := h.ownWishlist(c)
+	if !ok {
+		return
+	}
+	if err := h.Favorites.Share(c.Request.Context(), w); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to share wishlist"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"url":   "/api/v1/wishlists/shared/" + *w.ShareToken,
+		"token": *w.ShareToken,
+	})
+}
+
+// UnshareWishlist revokes the share link; sharing again issues a new one.
+func (h *FavoriteHandler) UnshareWishlist(c *gin.Context) {
+	w, ok := h.ownWishlist(c)
+	if !ok {
+		return
+	}
+	if err := h.Favorites.Unshare(c.Request.Context(), w); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unshare wishlist"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"wishlist": w})
+}
+
+// ownWishlist loads the signed-in user's wishlist named by :id, writing the
+// error response itself when it can't.
+func (h *FavoriteHandler) ownWishlist(c *gin.Context) (*models.Wishlist, bool) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		return nil, false
+	}
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "wishlist not found"})
+		return nil, false
+	}
+	w, err := h.Favorites.Wishlist(c.Request.Context(), uid, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "wishlist not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch wishlist"})
+		return nil, false
+	}
+	return w, true
+}
+
+// respondFavoriteError maps favorite and wishlist errors to responses.
+func respondFavoriteError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "property not found"})
+	case errors.Is(err, services.ErrTooManyWishlists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update favorites"})
+	}
+}
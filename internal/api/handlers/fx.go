@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+	"github.com/olamideolayemi/realestate-backend/internal/services"
+	"github.com/olamideolayemi/realestate-backend/internal/utils"
+)
+
+const maxFXUpload = 1 << 20 // 1MB
+
+// FXHandler maintains the exchange rates used for display prices.
+type FXHandler struct {
+	DB *gorm.DB
+	FX *services.FXService
+}
+
+type FXRateRequest struct {
+	BaseCurrency  string `json:"base_currency" binding:"required"`
+	QuoteCurrency string `json:"quote_currency" binding:"required"`
+	Rate          string `json:"rate" binding:"required"` // quote units per base unit, e.g. "0.00065"
+}
+
+func (h *FXHandler) ListRates(c *gin.Context) {
+	page, ok := paginate(c, utils.MaxPageLimit,
+		utils.SortColumn{Column: "base_currency"}, utils.SortColumn{Column: "quote_currency"})
+	if !ok {
+		return
+	}
+	var rates []models.FXRate
+	if err := h.DB.Scopes(page.Scope).Find(&rates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch rates"})
+		return
+	}
+	rates = rates[:page.Trim(len(rates), func(i int) []interface{} {
+		return []interface{}{rates[i].BaseCurrency, rates[i].QuoteCurrency}
+	})]
+	respondPage(c, page, gin.H{"rates": rates})
+}
+
+// SetRate creates or replaces the rate for a currency pair.
+func (h *FXHandler) SetRate(c *gin.Context) {
+	var req FXRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	rate, err := h.FX.SetRate(h.DB, req.BaseCurrency, req.QuoteCurrency, req.Rate, "manual", staffID(c))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCurrency) || errors.Is(err, services.ErrInvalidFXRate) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save rate"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rate": rate})
+}
+
+// ImportRates upserts rates from a CSV of base,quote,rate rows, sent as a
+// multipart "file" or as the raw request body.
+func (h *FXHandler) ImportRates(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxFXUpload)
+	var r io.Reader
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+			return
+		}
+		f, err := fh.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "could not read file"})
+			return
+		}
+		defer f.Close()
+		r = f
+	} else {
+		r = c.Request.Body
+	}
+
+	res, err := h.FX.ImportCSV(r, staffID(c))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.Is(err, services.ErrInvalidCSV):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.As(err, &tooLarge):
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "rates file too large"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to import rates"})
+		}
+		return
+	}
+	if len(res.Errors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no rates imported; fix the listed rows", "result": res})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": res})
+}
+
+func (h *FXHandler) DeleteRate(c *gin.Context) {
+	res := h.DB.Delete(&models.FXRate{}, "id = ?", c.Param("id"))
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete rate"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "rate not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Rate removed"})
+}
+
+// displayCurrency reads the optional display_currency query parameter,
+// writing a 400 when it is malformed.
+func displayCurrency(c *gin.Context) (string, bool) {
+	v := c.Query("display_currency")
+	if v == "" {
+		return "", true
+	}
+	cur, err := services.NormalizeCurrency(v)
+	if err != nil {
+		respondFilterError(c, &services.FilterError{Fields: map[string]string{"display_currency": err.Error()}})
+		return "", false
+	}
+	return cur, true
+}
+
+// respondFXError writes the response for a failed display conversion.
+func respondFXError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrNoFXRate) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to convert prices"})
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+	"github.com/olamideolayemi/realestate-backend/internal/services"
+	"github.com/olamideolayemi/realestate-backend/internal/utils"
+)
+
+// currentUserID returns the user set by AuthMiddleware, writing the error
+// response itself when there is none.
+func currentUserID(c *gin.Context) (uuid.UUID, bool) {
+	userIDval, exists := c.Get("currentUser")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return uuid.Nil, false
+	}
+	userID, ok := userIDval.(uuid.UUID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid user in context"})
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+// staffID returns the signed-in user, if any, for audit fields and other
+// optional personalisation.
+func staffID(c *gin.Context) *uuid.UUID {
+	if uid, ok := c.Get("currentUser"); ok {
+		if id, ok := uid.(uuid.UUID); ok {
+			return &id
+		}
+	}
+	return nil
+}
+
+// can reports whether the signed-in user's role, if any, grants perm.
+func can(c *gin.Context, perm models.Permission) bool {
+	r, _ := c.Get("currentUserRole")
+	role, ok := r.(string)
+	return ok && models.HasPermission(role, perm)
+}
+
+// parseAmount reads a non-negative decimal amount sent as a JSON number or
+// string. An empty value is zero.
+func parseAmount(field string, n json.Number, currency string) (models.Money, error) {
+	if n == "" {
+		return models.NewMoney(0, currency), nil
+	}
+	m, err := models.ParseMoney(n.String(), currency)
+	if err != nil || m.IsNegative() {
+		return models.Money{}, fmt.Errorf("invalid %s: must be a non-negative amount with at most %d decimal places", field, models.CurrencyExponent(currency))
+	}
+	return m, nil
+}
+
+// normalizeCurrency upper-cases an ISO 4217 code, defaulting to NGN.
+func normalizeCurrency(code string) (string, error) {
+	if strings.TrimSpace(code) == "" {
+		return "NGN", nil
+	}
+	return services.NormalizeCurrency(code)
+}
+
+// respondFilterError writes a 400 listing every bad query parameter:
+// {"error": "invalid query parameters", "fields": {"min_price": "..."}}.
+func respondFilterError(c *gin.Context, err error) {
+	var fe *services.FilterError
+	var pe *utils.PageError
+	switch {
+	case errors.As(err, &fe):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query parameters", "fields": fe.Fields})
+	case errors.As(err, &pe):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query parameters", "fields": gin.H{pe.Param: pe.Message}})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
+
+// paginate reads page, limit and cursor for a list in the given order,
+// writing the error response itself when they're bad.
+func paginate(c *gin.Context, defaultLimit int, order ...utils.SortColumn) (*utils.Paginator, bool) {
+	p, err := utils.NewPaginator(c.Request.URL, defaultLimit)
+	if err == nil {
+		err = p.Order(order...)
+	}
+	if err != nil {
+		respondFilterError(c, err)
+		return nil, false
+	}
+	return p, true
+}
+
+// respondPage writes a page of a list with its paging fields and Link header.
+func respondPage(c *gin.Context, p *utils.Paginator, body gin.H) {
+	if err := p.Err(); err != nil {
+		log.Printf("paging %s: %v", c.Request.URL.Path, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to page results"})
+		return
+	}
+	if link := p.Link(); link != "" {
+		c.Header("Link", link)
+	}
+	for k, v := range p.Meta() {
+		body[k] = v
+	}
+	c.JSON(http.StatusOK, body)
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+	"github.com/olamideolayemi/realestate-backend/internal/services"
+)
+
+// maxImagesPerUpload caps the files accepted in one multipart request.
+const maxImagesPerUpload = 10
+
+// ImageHandler manages a property's photo gallery. With OwnerOnly set it
+// only finds properties owned by the signed-in user, for the agent routes,
+// and sends published listings whose photos change back to review.
+type ImageHandler struct {
+	DB        *gorm.DB
+	Images    *services.ImageService
+	Listings  *services.ListingService
+	OwnerOnly bool
+}
+
+type ReorderImagesRequest struct {
+	ImageIDs []uuid.UUID `json:"image_ids" binding:"required"`
+}
+
+// property loads the property in the URL, writing the error response itself
+// when it can't.
+func (h *ImageHandler) property(c *gin.Context) (*models.Property, bool) {
+	q := h.DB.Select("id")
+	if h.OwnerOnly {
+		uid, ok := currentUserID(c)
+		if !ok {
+			return nil, false
+		}
+		q = q.Scopes(services.OwnedBy(uid))
+	}
+	var p models.Property
+	if err := q.First(&p, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "property not found"})
+		return nil, false
+	}
+	return &p, true
+}
+
+// resubmit sends an owner's published listing back to review before its
+// photos change, writing the error response itself when it can't.
+func (h *ImageHandler) resubmit(c *gin.Context, p *models.Property) bool {
+	if !h.OwnerOnly {
+		return true
+	}
+	if err := h.Listings.ResubmitListing(c.Request.Context(), p.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to send listing for review"})
+		return false
+	}
+	return true
+}
+
+// UploadImages adds photos sent as multipart files in the "images" field.
+// Every file is checked before any is stored.
+func (h *ImageHandler) UploadImages(c *gin.Context) {
+	p, ok := h.property(c)
+	if !ok {
+		return
+	}
+	maxSize := h.Images.MaxSizeBytes()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImagesPerUpload*maxSize+1<<20)
+	form, err := c.MultipartForm()
+	if err != nil {
+		var tooBig *http.MaxBytesError
+		if errors.As(err, &tooBig) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "upload is too large"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expected a multipart form with files in the images field"})
+		return
+	}
+	files := form.File["images"]
+	if len(files) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "attach at least one file in the images field"})
+		return
+	}
+	if len(files) > maxImagesPerUpload {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d images per upload", maxImagesPerUpload)})
+		return
+	}
+
+	uploads := make([][]byte, len(files))
+	for i, fh := range files {
+		if fh.Size > maxSize {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("%s is larger than %d MB", fh.Filename, maxSize>>20)})
+			return
+		}
+		f, err := fh.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "could not read " + fh.Filename})
+			return
+		}
+		data, err := io.ReadAll(io.LimitReader(f, maxSize+1))
+		f.Close()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "could not read " + fh.Filename})
+			return
+		}
+		if _, err := services.SniffImage(data); err != nil {
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": fh.Filename + ": " + err.Error()})
+			return
+		}
+		uploads[i] = data
+	}
+
+	if !h.resubmit(c, p) {
+		return
+	}
+	alt := c.PostForm("alt_text")
+	created := make([]*models.PropertyImage, 0, len(uploads))
+	for i, data := range uploads {
+		img, err := h.Images.Upload(c.Request.Context(), p.ID, data, alt)
+		if err != nil {
+			respondImageError(c, err, gin.H{"file": files[i].Filename, "uploaded": created})
+			return
+		}
+		created = append(created, img)
+	}
+	c.JSON(http.StatusCreated, gin.H{"images": created})
+}
+
+// ReorderImages sets the gallery order from a list of every image id.
+func (h *ImageHandler) ReorderImages(c *gin.Context) {
+	p, ok := h.property(c)
+	if !ok {
+		return
+	}
+	var req ReorderImagesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	imgs, err := h.Images.Reorder(c.Request.Context(), p.ID, req.ImageIDs)
+	if err != nil {
+		respondImageError(c, err, nil)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"images": imgs})
+}
+
+// SetCoverImage makes one image the property's cover.
+func (h *ImageHandler) SetCoverImage(c *gin.Context) {
+	p, ok := h.property(c)
+	if !ok {
+		return
+	}
+	imageID, err := uuid.Parse(c.Param("imageId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "image not found"})
+		return
+	}
+	if !h.resubmit(c, p) {
+		return
+	}
+	img, err := h.Images.SetCover(c.Request.Context(), p.ID, imageID)
+	if err != nil {
+		respondImageError(c, err, nil)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"image": img})
+}
+
+// DeleteImage removes an image and its stored file.
+func (h *ImageHandler) DeleteImage(c *gin.Context) {
+	p, ok := h.property(c)
+	if !ok {
+		return
+	}
+	imageID, err := uuid.Parse(c.Param("imageId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "image not found"})
+		return
+	}
+	if !h.resubmit(c, p) {
+		return
+	}
+	if err := h.Images.Delete(c.Request.Context(), p.ID, imageID); err != nil {
+		respondImageError(c, err, nil)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Image deleted"})
+}
+
+// respondImageError maps image errors to responses, adding extra fields.
+func respondImageError(c *gin.Context, err error, extra gin.H) {
+	status := http.StatusInternalServerError
+	msg := "failed to update images"
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		status, msg = http.StatusNotFound, "image not found"
+	case errors.Is(err, services.ErrUnsupportedImage):
+		status, msg = http.StatusUnsupportedMediaType, err.Error()
+	case errors.Is(err, services.ErrImageTooLarge):
+		status, msg = http.StatusRequestEntityTooLarge, err.Error()
+	case errors.Is(err, services.ErrTooManyImages):
+		status, msg = http.StatusConflict, err.Error()
+	case errors.Is(err, services.ErrImageOrder):
+		status, msg = http.StatusBadRequest, err.Error()
+	}
+	body := gin.H{"error": msg}
+	for k, v := range extra {
+		body[k] = v
+	}
+	c.JSON(status, body)
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/realestate-backend/internal/services"
+	"github.com/olamideolayemi/realestate-backend/internal/utils"
+)
+
+// ListingReviewHandler is the admin approval queue for agents' listings.
+type ListingReviewHandler struct {
+	DB       *gorm.DB
+	Listings *services.ListingService
+}
+
+type RejectListingRequest struct {
+	Note string `json:"note" binding:"required"`
+}
+
+// ReviewQueue lists listings waiting for approval, oldest first.
+func (h *ListingReviewHandler) ReviewQueue(c *gin.Context) {
+	page, ok := paginate(c, utils.DefaultPageLimit, services.ReviewQueueOrder...)
+	if !ok {
+		return
+	}
+	props, err := h.Listings.ReviewQueue(c.Request.Context(), page.Scope)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch review queue"})
+		return
+	}
+	props = props[:page.Trim(len(props), func(i int) []interface{} {
+		return []interface{}{*props[i].SubmittedAt, props[i].ID}
+	})]
+	respondPage(c, page, gin.H{"properties": props})
+}
+
+// ApproveListing publishes a listing under review.
+func (h *ListingReviewHandler) ApproveListing(c *gin.Context) {
+	h.review(c, true, "")
+}
+
+// RejectListing sends a listing back to its owner as a draft with a note.
+func (h *ListingReviewHandler) RejectListing(c *gin.Context) {
+	var req RejectListingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.review(c, false, req.Note)
+}
+
+func (h *ListingReviewHandler) review(c *gin.Context, approve bool, note string) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "property not found"})
+		return
+	}
+	p, err := h.Listings.Review(c.Request.Context(), id, approve, uid, note)
+	if err != nil {
+		respondListingError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"property": p})
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+	"github.com/olamideolayemi/realestate-backend/internal/services"
+)
+
+// maxWebhookBody bounds webhook payloads read into memory.
+const maxWebhookBody = 1 << 20
+
+type PaymentHandler struct {
+	DB       *gorm.DB
+	Payments *services.PaymentService
+}
+
+// PayBooking starts checkout for one of the caller's pending bookings.
+func (h *PaymentHandler) PayBooking(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var booking models.Booking
+	if err := h.DB.First(&booking, "id = ? AND user_id = ?", c.Param("id"), userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "booking not found"})
+		return
+	}
+	var user models.User
+	if err := h.DB.First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	payment, err := h.Payments.Checkout(c.Request.Context(), &booking, user.Email)
+	if err != nil {
+		if errors.Is(err, services.ErrBookingNotPayable) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		log.Println("payment checkout error:", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to start payment"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"reference":         payment.Reference,
+		"authorization_url": payment.AuthorizationURL,
+		"access_code":       payment.AccessCode,
+		"payment":           payment,
+	})
+}
+
+// Webhook receives gateway notifications. Only deliveries with a valid
+// signature are applied; events we can never apply are acknowledged so the
+// gateway stops retrying them.
+func (h *PaymentHandler) Webhook(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookBody))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "could not read body"})
+		return
+	}
+	ev, err := h.Payments.Gateway.ParseWebhook(body, c.Request.Header)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidSignature) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.Payments.HandleWebhook(c.Request.Context(), ev); err != nil {
+		if errors.Is(err, services.ErrUnknownPayment) || errors.Is(err, services.ErrAmountMismatch) {
+			log.Printf("payment webhook %s for %s ignored: %v", ev.Event, ev.Reference, err)
+			c.JSON(http.StatusOK, gin.H{"ok": true})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to process webhook"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
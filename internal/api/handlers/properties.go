@@ -1,7 +1,11 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -9,25 +13,194 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/olamideolayemi/realestate-backend/internal/models"
+	"github.com/olamideolayemi/realestate-backend/internal/services"
+	"github.com/olamideolayemi/realestate-backend/internal/utils"
 )
 
 type PropertyHandler struct {
-	DB *gorm.DB
+	DB           *gorm.DB
+	Availability *services.AvailabilityService
+	Pricing      *services.PricingService
+	FX           *services.FXService
+	Images       *services.ImageService
+	Favorites    *services.FavoriteService
+	Listings     *services.ListingService
+}
+
+// PropertyDisplay is a property's prices converted to the requested
+// display_currency. Bookings are still charged in the property's currency.
+type PropertyDisplay struct {
+	Currency       string       `json:"currency"`
+	Rate           string       `json:"rate"`
+	RateAsOf       time.Time    `json:"rate_as_of"`
+	Price          models.Money `json:"price"`
+	ExtraGuestFee  models.Money `json:"extra_guest_fee"`
+	CleaningFee    models.Money `json:"cleaning_fee"`
+	CautionDeposit models.Money `json:"caution_deposit"`
+}
+
+// propertyView is a property as returned by the public endpoints.
+type propertyView struct {
+	models.Property
+	Display *PropertyDisplay `json:"display,omitempty"`
+	// Search is set when the list was filtered by a q keyword search.
+	Search *services.SearchHit `json:"search,omitempty"`
+	// DistanceKm is from the lat/lng in the query, when given.
+	DistanceKm *float64 `json:"distance_km,omitempty"`
+	// IsFavorite is set when the caller is signed in.
+	IsFavorite *bool `json:"is_favorite,omitempty"`
+}
+
+// propertyViews wraps props for output, converting prices when conv is set.
+func propertyViews(props []models.Property, conv *services.Converter) ([]propertyView, error) {
+	views := make([]propertyView, len(props))
+	for i := range props {
+		views[i].Property = props[i]
+		if conv == nil {
+			continue
+		}
+		fx, err := conv.Quote(services.PropertyCurrency(&props[i]))
+		if err != nil {
+			return nil, err
+		}
+		d := &PropertyDisplay{Currency: fx.To, Rate: fx.Rate, RateAsOf: fx.AsOf}
+		for _, f := range []struct {
+			dst *models.Money
+			src models.Money
+		}{
+			{&d.Price, props[i].Price},
+			{&d.ExtraGuestFee, props[i].ExtraGuestFee},
+			{&d.CleaningFee, props[i].CleaningFee},
+			{&d.CautionDeposit, props[i].CautionDeposit},
+		} {
+			src := models.NewMoney(f.src.Amount, fx.From)
+			if *f.dst, err = fx.Convert(src); err != nil {
+				return nil, err
+			}
+		}
+		views[i].Display = d
+	}
+	return views, nil
 }
 
 type CreatePropertyRequest struct {
-	Title        string  `json:"title" binding:"required"`
-	Description  string  `json:"description"`
-	Category     string  `json:"category" binding:"required"` // buy|rent|shortlet
-	Price        float64 `json:"price" binding:"required"`
-	Currency     string  `json:"currency"`
-	Address      string  `json:"address"`
-	Area         string  `json:"area"`
-	Bedrooms     int     `json:"bedrooms"`
-	Bathrooms    int     `json:"bathrooms"`
-	Furnished    bool    `json:"furnished"`
-	PartyAllowed bool    `json:"party_allowed"`
-	InstantBook  bool    `json:"instant_book"`
+	Title        string      `json:"title" binding:"required"`
+	Description  string      `json:"description"`
+	Category     string      `json:"category" binding:"required"` // buy|rent|shortlet
+	Price        json.Number `json:"price" binding:"required"`    // major units, e.g. 150000 or "150000.50"
+	Currency     string      `json:"currency"`                    // ISO 4217, defaults to NGN
+	Address      string      `json:"address"`
+	Area         string      `json:"area"`
+	Latitude     *float64    `json:"latitude"`  // ignored while an admin's geocode override is set
+	Longitude    *float64    `json:"longitude"` // ignored while an admin's geocode override is set
+	Bedrooms     int         `json:"bedrooms"`
+	Bathrooms    int         `json:"bathrooms"`
+	Furnished    bool        `json:"furnished"`
+	PartyAllowed bool        `json:"party_allowed"`
+	InstantBook  bool        `json:"instant_book"`
+	MinNights    int         `json:"min_nights"`
+	MaxNights    int         `json:"max_nights"`
+	// flexible|moderate|strict, defaults to moderate
+	CancellationPolicy string      `json:"cancellation_policy"`
+	WeekendUplift      int         `json:"weekend_uplift" binding:"min=0,max=500"`
+	WeeklyDiscount     int         `json:"weekly_discount" binding:"min=0,max=100"`
+	MonthlyDiscount    int         `json:"monthly_discount" binding:"min=0,max=100"`
+	BaseGuests         int         `json:"base_guests" binding:"min=0"` // guests the price covers; 0 means any number
+	ExtraGuestFee      json.Number `json:"extra_guest_fee"`             // per night for each guest beyond base_guests
+	CleaningFee        json.Number `json:"cleaning_fee"`
+	CautionDeposit     json.Number `json:"caution_deposit"`
+}
+
+// propertyAmounts holds a request's money fields parsed in its currency.
+type propertyAmounts struct {
+	Currency       string
+	Price          models.Money
+	ExtraGuestFee  models.Money
+	CleaningFee    models.Money
+	CautionDeposit models.Money
+}
+
+// amounts validates the currency and parses the money fields exactly.
+func (r *CreatePropertyRequest) amounts() (a propertyAmounts, err error) {
+	if a.Currency, err = normalizeCurrency(r.Currency); err != nil {
+		return a, err
+	}
+	if a.Price, err = parseAmount("price", r.Price, a.Currency); err != nil {
+		return a, err
+	}
+	if a.ExtraGuestFee, err = parseAmount("extra_guest_fee", r.ExtraGuestFee, a.Currency); err != nil {
+		return a, err
+	}
+	if a.CleaningFee, err = parseAmount("cleaning_fee", r.CleaningFee, a.Currency); err != nil {
+		return a, err
+	}
+	if a.CautionDeposit, err = parseAmount("caution_deposit", r.CautionDeposit, a.Currency); err != nil {
+		return a, err
+	}
+	return a, nil
+}
+
+// apply validates the request and copies its fields onto p.
+func (r *CreatePropertyRequest) apply(p *models.Property) error {
+	policy, err := r.cancellationPolicy()
+	if err != nil {
+		return err
+	}
+	amounts, err := r.amounts()
+	if err != nil {
+		return err
+	}
+	if !p.GeocodeOverride {
+		if err := setLocation(p, r.Latitude, r.Longitude); err != nil {
+			return err
+		}
+	}
+	p.Title = r.Title
+	p.Description = r.Description
+	p.Category = r.Category
+	p.Price = amounts.Price
+	p.Currency = amounts.Currency
+	p.Address = r.Address
+	p.Area = r.Area
+	p.Bedrooms = r.Bedrooms
+	p.Bathrooms = r.Bathrooms
+	p.Furnished = r.Furnished
+	p.PartyAllowed = r.PartyAllowed
+	p.InstantBook = r.InstantBook
+	p.MinNights = r.MinNights
+	p.MaxNights = r.MaxNights
+	p.CancellationPolicy = policy
+	p.WeekendUplift = r.WeekendUplift
+	p.WeeklyDiscount = r.WeeklyDiscount
+	p.MonthlyDiscount = r.MonthlyDiscount
+	p.BaseGuests = r.BaseGuests
+	p.ExtraGuestFee = amounts.ExtraGuestFee
+	p.CleaningFee = amounts.CleaningFee
+	p.CautionDeposit = amounts.CautionDeposit
+	return nil
+}
+
+// setLocation validates and sets p's coordinates; both or neither.
+func setLocation(p *models.Property, lat, lng *float64) error {
+	if (lat == nil) != (lng == nil) {
+		return errors.New("latitude and longitude must be given together")
+	}
+	if lat != nil && !(services.GeoPoint{Lat: *lat, Lng: *lng}).Valid() {
+		return services.ErrInvalidCoordinates
+	}
+	p.Latitude, p.Longitude = lat, lng
+	return nil
+}
+
+// cancellationPolicy validates the requested policy, applying the default.
+func (r *CreatePropertyRequest) cancellationPolicy() (string, error) {
+	if r.CancellationPolicy == "" {
+		return services.DefaultCancellationPolicy, nil
+	}
+	if !services.ValidCancellationPolicy(r.CancellationPolicy) {
+		return "", services.ErrUnknownPolicy
+	}
+	return r.CancellationPolicy, nil
 }
 
 func (h *PropertyHandler) CreateProperty(c *gin.Context) {
@@ -36,22 +209,19 @@ func (h *PropertyHandler) CreateProperty(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	// staff listings skip the review queue; the route needs PermPropertyManage
+	now := time.Now()
 	p := models.Property{
-		ID:           uuid.New(),
-		Title:        req.Title,
-		Description:  req.Description,
-		Category:     req.Category,
-		Price:        req.Price,
-		Currency:     req.Currency,
-		Address:      req.Address,
-		Area:         req.Area,
-		Bedrooms:     req.Bedrooms,
-		Bathrooms:    req.Bathrooms,
-		Furnished:    req.Furnished,
-		PartyAllowed: req.PartyAllowed,
-		InstantBook:  req.InstantBook,
-		CreatedAt:    time.Now(),
-		UpdatedAt:    time.Now(),
+		ID:          uuid.New(),
+		OwnerID:     staffID(c),
+		Status:      models.PropertyPublished,
+		PublishedAt: &now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := req.apply(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 	if err := h.DB.Create(&p).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create property"})
@@ -60,47 +230,255 @@ func (h *PropertyHandler) CreateProperty(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{"property": p})
 }
 
+// ListProperties searches the listings the caller may see: the published
+// catalogue, plus their own unpublished listings for owners and every
+// listing for staff who manage properties.
 func (h *PropertyHandler) ListProperties(c *gin.Context) {
-	// Basic filter implementation
-	category := c.Query("category") // buy|rent|shortlet
-	area := c.Query("area")
-	minBeds := c.Query("min_beds")
-	checkin := c.Query("checkin")
-	checkout := c.Query("checkout")
-
-	// Build query
-	q := h.DB.Model(&models.Property{}).Preload("Images")
-	if category != "" {
-		q = q.Where("category = ?", category)
+	display, ok := displayCurrency(c)
+	if !ok {
+		return
 	}
-	if area != "" {
-		q = q.Where("area = ?", area)
+
+	filter, err := services.ParsePropertyFilter(c.Request.URL.Query())
+	if err != nil {
+		respondFilterError(c, err)
+		return
 	}
-	if minBeds != "" {
-		q = q.Where("bedrooms >= ?", minBeds)
+	page, ok := paginate(c, utils.DefaultPageLimit, filter.SortColumns()...)
+	if !ok {
+		return
 	}
-
-	// If category is shortlet and dates present, filter out properties with conflicting bookings
-	if category == "shortlet" && checkin != "" && checkout != "" {
-		q = q.Where("NOT EXISTS (SELECT 1 FROM bookings b WHERE b.property_id = properties.id AND b.status IN ('confirmed','pending') AND NOT (b.checkout <= ? OR b.checkin >= ?))", checkin, checkout)
+	q := h.DB.Model(&models.Property{}).Scopes(visibleProperties(c), filter.Where, filter.Order, page.Scope).
+		Preload("Images", services.ImagesInOrder)
+	if filter.CheckIn != nil {
+		q = q.Scopes(h.Availability.AvailableBetween(*filter.CheckIn, *filter.CheckOut))
 	}
 
 	var props []models.Property
-	if err := q.Limit(100).Find(&props).Error; err != nil {
+	if err := q.Find(&props).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch properties"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"properties": props})
+	props = props[:page.Trim(len(props), func(i int) []interface{} { return filter.SortKey(&props[i]) })]
+	views, err := propertyViews(props, h.converter(display))
+	if err != nil {
+		respondFXError(c, err)
+		return
+	}
+	if filter.Near != nil {
+		for i := range views {
+			if lat, lng := views[i].Latitude, views[i].Longitude; lat != nil && lng != nil {
+				d := math.Round(services.DistanceKm(*filter.Near, services.GeoPoint{Lat: *lat, Lng: *lng})*100) / 100
+				views[i].DistanceKm = &d
+			}
+		}
+	}
+	if search := filter.Search; search != nil {
+		ids := make([]uuid.UUID, len(props))
+		for i := range props {
+			ids[i] = props[i].ID
+		}
+		hits, err := search.Hits(h.DB, ids)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch properties"})
+			return
+		}
+		for i := range views {
+			if hit, ok := hits[views[i].ID]; ok {
+				views[i].Search = &hit
+			}
+		}
+	}
+	if !h.markFavorites(c, views) {
+		return
+	}
+	hideReviewFields(c, views)
+	respondPage(c, page, gin.H{"properties": views})
 }
 
 func (h *PropertyHandler) GetProperty(c *gin.Context) {
+	display, ok := displayCurrency(c)
+	if !ok {
+		return
+	}
 	id := c.Param("id")
 	var p models.Property
-	if err := h.DB.Preload("Images").First(&p, "id = ?", id).Error; err != nil {
+	if err := h.DB.Scopes(visibleProperties(c)).Preload("Images", services.ImagesInOrder).First(&p, "id = ?", id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "property not found"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"property": p})
+	views, err := propertyViews([]models.Property{p}, h.converter(display))
+	if err != nil {
+		respondFXError(c, err)
+		return
+	}
+	if !h.markFavorites(c, views) {
+		return
+	}
+	hideReviewFields(c, views)
+	c.JSON(http.StatusOK, gin.H{"property": views[0]})
+}
+
+// visibleProperties limits a property query to the listings the caller may
+// see: staff who manage properties see them all, signed-in owners also see
+// their own unpublished ones, and everyone else only published listings.
+func visibleProperties(c *gin.Context) func(*gorm.DB) *gorm.DB {
+	if can(c, models.PermPropertyManage) {
+		return func(db *gorm.DB) *gorm.DB { return db }
+	}
+	if uid := staffID(c); uid != nil {
+		return services.PublishedOrOwnedBy(*uid)
+	}
+	return services.Published
+}
+
+// hideReviewFields clears the listing review fields of views, except for
+// staff who manage properties and the listing's owner.
+func hideReviewFields(c *gin.Context, views []propertyView) {
+	if can(c, models.PermPropertyManage) {
+		return
+	}
+	uid := staffID(c)
+	for i := range views {
+		p := &views[i].Property
+		if uid != nil && p.OwnerID != nil && *p.OwnerID == *uid {
+			continue
+		}
+		p.ReviewNote, p.SubmittedAt, p.ReviewedBy = "", nil, nil
+	}
+}
+
+// markFavorites sets IsFavorite on views for a signed-in caller, writing
+// the error response itself when it fails.
+func (h *PropertyHandler) markFavorites(c *gin.Context, views []propertyView) bool {
+	uid := staffID(c)
+	if uid == nil || h.Favorites == nil {
+		return true
+	}
+	ids := make([]uuid.UUID, len(views))
+	for i := range views {
+		ids[i] = views[i].ID
+	}
+	favs, err := h.Favorites.FavoriteSet(c.Request.Context(), *uid, ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch favorites"})
+		return false
+	}
+	for i := range views {
+		fav := favs[views[i].ID]
+		views[i].IsFavorite = &fav
+	}
+	return true
+}
+
+// converter returns a converter into currency, or nil when none was asked for.
+func (h *PropertyHandler) converter(currency string) *services.Converter {
+	if currency == "" {
+		return nil
+	}
+	return h.FX.NewConverter(currency)
+}
+
+// GetAvailability returns the per-night availability calendar of a property.
+// Defaults to the 30 nights starting today.
+func (h *PropertyHandler) GetAvailability(c *gin.Context) {
+	var p models.Property
+	if err := h.DB.Scopes(visibleProperties(c)).First(&p, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "property not found"})
+		return
+	}
+
+	from := time.Now().UTC().Truncate(24 * time.Hour)
+	if v := c.Query("from"); v != "" {
+		d, err := services.ParseDate(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from date; use YYYY-MM-DD"})
+			return
+		}
+		from = d
+	}
+	to := from.AddDate(0, 0, 30)
+	if v := c.Query("to"); v != "" {
+		d, err := services.ParseDate(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to date; use YYYY-MM-DD"})
+			return
+		}
+		to = d
+	}
+
+	days, err := h.Availability.Calendar(p.ID, from, to)
+	if err != nil {
+		respondAvailabilityError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"property_id": p.ID,
+		"from":        from.Format(services.DateLayout),
+		"to":          to.Format(services.DateLayout),
+		"min_nights":  p.MinNights,
+		"max_nights":  p.MaxNights,
+		"days":        days,
+	})
+}
+
+// GetQuote prices a stay with an itemised breakdown and says whether the
+// dates are currently free.
+func (h *PropertyHandler) GetQuote(c *gin.Context) {
+	var p models.Property
+	if err := h.DB.Scopes(visibleProperties(c)).First(&p, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "property not found"})
+		return
+	}
+	checkin, err := services.ParseDate(c.Query("checkin"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid checkin date; use YYYY-MM-DD"})
+		return
+	}
+	checkout, err := services.ParseDate(c.Query("checkout"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid checkout date; use YYYY-MM-DD"})
+		return
+	}
+	display, ok := displayCurrency(c)
+	if !ok {
+		return
+	}
+	guests := 1
+	if v := c.Query("guests"); v != "" {
+		if guests, err = strconv.Atoi(v); err != nil || guests < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "guests must be a positive number"})
+			return
+		}
+	}
+
+	if _, err := h.Availability.ValidateStay(&p, checkin, checkout); err != nil {
+		respondAvailabilityError(c, err)
+		return
+	}
+	bookings, blocks, err := h.Availability.Conflicts(h.DB, p.ID, checkin, checkout)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed availability check"})
+		return
+	}
+	quote, err := h.Pricing.Quote(h.DB, &p, checkin, checkout, guests)
+	if err != nil {
+		respondPricingError(c, err, "failed to price stay")
+		return
+	}
+	resp := gin.H{
+		"quote":     quote,
+		"available": bookings == 0 && blocks == 0,
+	}
+	if display != "" {
+		d, err := h.FX.DisplayQuote(quote, display)
+		if err != nil {
+			respondFXError(c, err)
+			return
+		}
+		resp["display"] = d
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
 func (h *PropertyHandler) UpdateProperty(c *gin.Context) {
@@ -115,18 +493,10 @@ func (h *PropertyHandler) UpdateProperty(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "property not found"})
 		return
 	}
-	p.Title = req.Title
-	p.Description = req.Description
-	p.Category = req.Category
-	p.Price = req.Price
-	p.Currency = req.Currency
-	p.Address = req.Address
-	p.Area = req.Area
-	p.Bedrooms = req.Bedrooms
-	p.Bathrooms = req.Bathrooms
-	p.Furnished = req.Furnished
-	p.PartyAllowed = req.PartyAllowed
-	p.InstantBook = req.InstantBook
+	if err := req.apply(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	p.UpdatedAt = time.Now()
 
 	if err := h.DB.Save(&p).Error; err != nil {
@@ -136,10 +506,71 @@ func (h *PropertyHandler) UpdateProperty(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"property": p})
 }
 
+type SetLocationRequest struct {
+	Latitude  *float64 `json:"latitude" binding:"required"`
+	Longitude *float64 `json:"longitude" binding:"required"`
+}
+
+// SetLocation pins a property's coordinates by hand, overriding whatever the
+// owner enters until ClearLocationOverride.
+func (h *PropertyHandler) SetLocation(c *gin.Context) {
+	var req SetLocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var p models.Property
+	if err := h.DB.First(&p, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "property not found"})
+		return
+	}
+	if err := setLocation(&p, req.Latitude, req.Longitude); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := h.DB.Model(&p).Updates(map[string]interface{}{
+		"latitude":         p.Latitude,
+		"longitude":        p.Longitude,
+		"geocode_override": true,
+	}).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update property"})
+		return
+	}
+	p.GeocodeOverride = true
+	c.JSON(http.StatusOK, gin.H{"property": p})
+}
+
+// ClearLocationOverride lets the owner edit the coordinates again. The
+// pinned position stays until they do.
+func (h *PropertyHandler) ClearLocationOverride(c *gin.Context) {
+	res := h.DB.Model(&models.Property{}).Where("id = ?", c.Param("id")).Update("geocode_override", false)
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update property"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "property not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Geocode override cleared"})
+}
+
 func (h *PropertyHandler) DeleteProperty(c *gin.Context) {
-	id := c.Param("id")
-	if err := h.DB.Delete(&models.Property{}, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete property"})
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "property not found"})
+		return
+	}
+	if err := h.Listings.DeleteAny(c.Request.Context(), id); err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "property not found"})
+		case errors.Is(err, services.ErrListingHasBookings):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete property"})
+		}
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{
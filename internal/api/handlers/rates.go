@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+	"github.com/olamideolayemi/realestate-backend/internal/services"
+	"github.com/olamideolayemi/realestate-backend/internal/utils"
+)
+
+type RateHandler struct {
+	DB *gorm.DB
+}
+
+type PropertyRateRequest struct {
+	Label        string      `json:"label" binding:"required"`
+	StartDate    string      `json:"start_date" binding:"required"`    // "YYYY-MM-DD", first night at this rate
+	EndDate      string      `json:"end_date" binding:"required"`      // "YYYY-MM-DD", day the rate ends
+	NightlyPrice json.Number `json:"nightly_price" binding:"required"` // in the property's currency
+	Priority     int         `json:"priority"`
+}
+
+func (r *PropertyRateRequest) parse() (start, end time.Time, err error) {
+	if start, err = services.ParseDate(r.StartDate); err != nil {
+		return start, end, errors.New("invalid start_date; use YYYY-MM-DD")
+	}
+	if end, err = services.ParseDate(r.EndDate); err != nil {
+		return start, end, errors.New("invalid end_date; use YYYY-MM-DD")
+	}
+	if !end.After(start) {
+		return start, end, errors.New("end_date must be after start_date")
+	}
+	return start, end, nil
+}
+
+// price parses the nightly price in currency; it must be positive.
+func (r *PropertyRateRequest) price(currency string) (models.Money, error) {
+	m, err := parseAmount("nightly_price", r.NightlyPrice, currency)
+	if err != nil {
+		return m, err
+	}
+	if !m.IsPositive() {
+		return m, errors.New("nightly_price must be greater than 0")
+	}
+	return m, nil
+}
+
+func (h *RateHandler) ListRates(c *gin.Context) {
+	page, ok := paginate(c, utils.MaxPageLimit,
+		utils.SortColumn{Column: "start_date"}, utils.SortColumn{Column: "id"})
+	if !ok {
+		return
+	}
+	var rates []models.PropertyRate
+	if err := h.DB.Where("property_id = ?", c.Param("id")).Scopes(page.Scope).Find(&rates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch rates"})
+		return
+	}
+	rates = rates[:page.Trim(len(rates), func(i int) []interface{} {
+		return []interface{}{rates[i].StartDate, rates[i].ID}
+	})]
+	respondPage(c, page, gin.H{"rates": rates})
+}
+
+func (h *RateHandler) CreateRate(c *gin.Context) {
+	var req PropertyRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	start, end, err := req.parse()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var prop models.Property
+	if err := h.DB.First(&prop, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "property not found"})
+		return
+	}
+	currency := services.PropertyCurrency(&prop)
+	price, err := req.price(currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rate := models.PropertyRate{
+		ID:           uuid.New(),
+		PropertyID:   prop.ID,
+		Label:        req.Label,
+		StartDate:    start,
+		EndDate:      end,
+		NightlyPrice: price,
+		Currency:     currency,
+		Priority:     req.Priority,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+	if err := h.DB.Create(&rate).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create rate"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"rate": rate})
+}
+
+func (h *RateHandler) UpdateRate(c *gin.Context) {
+	var req PropertyRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	start, end, err := req.parse()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var rate models.PropertyRate
+	if err := h.DB.First(&rate, "id = ? AND property_id = ?", c.Param("rateId"), c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "rate not found"})
+		return
+	}
+	var prop models.Property
+	if err := h.DB.Select("currency").First(&prop, "id = ?", rate.PropertyID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "property not found"})
+		return
+	}
+	currency := services.PropertyCurrency(&prop)
+	price, err := req.price(currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	rate.Label = req.Label
+	rate.StartDate = start
+	rate.EndDate = end
+	rate.NightlyPrice = price
+	rate.Currency = currency
+	rate.Priority = req.Priority
+	rate.UpdatedAt = time.Now()
+	if err := h.DB.Save(&rate).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update rate"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rate": rate})
+}
+
+func (h *RateHandler) DeleteRate(c *gin.Context) {
+	res := h.DB.Delete(&models.PropertyRate{}, "id = ? AND property_id = ?", c.Param("rateId"), c.Param("id"))
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete rate"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "rate not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Rate removed"})
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+	"github.com/olamideolayemi/realestate-backend/internal/services"
+	"github.com/olamideolayemi/realestate-backend/internal/utils"
+)
+
+// SavedSearchHandler manages the signed-in user's saved searches and the
+// public unsubscribe link from alert emails.
+type SavedSearchHandler struct {
+	DB       *gorm.DB
+	Searches *services.SavedSearchService
+}
+
+type CreateSavedSearchRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+	// Query is a ListProperties query string, e.g. "area=Lekki&min_bedrooms=2".
+	Query     string `json:"query"`
+	Frequency string `json:"frequency"` // instant|daily|weekly, defaults to daily
+}
+
+type UpdateSavedSearchRequest struct {
+	Name      *string `json:"name" binding:"omitempty,min=1,max=100"`
+	Query     *string `json:"query"`
+	Frequency *string `json:"frequency"`
+	Alerts    *bool   `json:"alerts"`
+}
+
+func (h *SavedSearchHandler) CreateSavedSearch(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req CreateSavedSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	search, err := h.Searches.Create(c.Request.Context(), uid, strings.TrimSpace(req.Name), req.Query, req.Frequency)
+	if err != nil {
+		respondSavedSearchError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"saved_search": search})
+}
+
+// ListSavedSearches lists the user's saved searches, newest first.
+func (h *SavedSearchHandler) ListSavedSearches(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	page, ok := paginate(c, utils.DefaultPageLimit,
+		utils.SortColumn{Column: "created_at", Desc: true},
+		utils.SortColumn{Column: "id", Desc: true})
+	if !ok {
+		return
+	}
+	var searches []models.SavedSearch
+	if err := h.DB.Where("user_id = ?", uid).Scopes(page.Scope).Find(&searches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch saved searches"})
+		return
+	}
+	searches = searches[:page.Trim(len(searches), func(i int) []interface{} {
+		return []interface{}{searches[i].CreatedAt, searches[i].ID}
+	})]
+	respondPage(c, page, gin.H{"saved_searches": searches})
+}
+
+// UpdateSavedSearch renames a search, changes its filters or frequency, or
+// turns its alerts on and off.
+func (h *SavedSearchHandler) UpdateSavedSearch(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req UpdateSavedSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var search models.SavedSearch
+	if err := h.DB.First(&search, "id = ? AND user_id = ?", c.Param("id"), uid).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "saved search not found"})
+		return
+	}
+	updates := map[string]interface{}{}
+	if req.Name != nil {
+		updates["name"] = strings.TrimSpace(*req.Name)
+	}
+	if req.Query != nil {
+		q, err := services.NormalizeSearchQuery(*req.Query)
+		if err != nil {
+			respondSavedSearchError(c, err)
+			return
+		}
+		updates["query"] = q
+		updates["alerts_off_reason"] = ""
+	}
+	if req.Frequency != nil {
+		if !models.ValidAlertFrequency(*req.Frequency) {
+			respondSavedSearchError(c, services.ErrInvalidFrequency)
+			return
+		}
+		updates["frequency"] = *req.Frequency
+	}
+	if req.Alerts != nil {
+		if *req.Alerts && req.Query == nil {
+			// the matcher may have turned alerts off for a query that no
+			// longer parses; don't switch them back on for it
+			if _, err := services.NormalizeSearchQuery(search.Query); err != nil {
+				respondSavedSearchError(c, err)
+				return
+			}
+		}
+		updates["alerts"] = *req.Alerts
+		updates["alerts_off_reason"] = ""
+	}
+	if len(updates) > 0 {
+		if err := h.DB.Model(&search).Updates(updates).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update saved search"})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"saved_search": search})
+}
+
+func (h *SavedSearchHandler) DeleteSavedSearch(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	res := h.DB.Where("id = ? AND user_id = ?", c.Param("id"), uid).Delete(&models.SavedSearch{})
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete saved search"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "saved search not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Saved search deleted"})
+}
+
+// Unsubscribe turns off a search's alerts from the link in its emails. It
+// needs no sign-in: the token is the credential.
+func (h *SavedSearchHandler) Unsubscribe(c *gin.Context) {
+	search, err := h.Searches.Unsubscribe(c.Request.Context(), c.Query("token"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "unsubscribe link is invalid"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unsubscribe"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "You will no longer get emails for " + search.Name})
+}
+
+// respondSavedSearchError maps saved search errors to responses.
+func respondSavedSearchError(c *gin.Context, err error) {
+	var fe *services.FilterError
+	switch {
+	case errors.As(err, &fe):
+		respondFilterError(c, err)
+	case errors.Is(err, services.ErrInvalidFrequency):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrTooManySavedSearches):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save search"})
+	}
+}
@@ -1,15 +1,17 @@
 package handlers
 
 import (
-	"fmt"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/olamideolayemi/realestate-backend/internal/models"
+	"github.com/olamideolayemi/realestate-backend/internal/utils"
 )
 
 type UsersHandler struct {
@@ -28,33 +30,30 @@ type UserResponse struct {
 
 // List all Users
 func (h *UsersHandler) ListUsers(c *gin.Context) {
-	page := 1
-	limit := 10
-	if p := c.Query("page"); p != "" {
-		fmt.Sscan(p, "%d", &page)
-		if page < 1 {
-			page = 1
-		}
-	}
-	if l := c.Query("limit"); l != "" {
-		fmt.Sscan(l, "%d", &limit)
-		if limit < 1 {
-			limit = 10
-		}
+	page, ok := paginate(c, 10,
+		utils.SortColumn{Column: "created_at", Desc: true},
+		utils.SortColumn{Column: "id", Desc: true})
+	if !ok {
+		return
 	}
 
-	offset := (page - 1) * limit
-
 	//Fetch total count for pagination
 	var total int64
-	h.DB.Model(&models.User{}).Count(&total)
+	if err := h.DB.Model(&models.User{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	page.Total = &total
 
 	//Fetch paginated results
 	var users []models.User
-	if err := h.DB.Offset(offset).Limit(limit).Order("created_at DESC").Find(&users).Error; err != nil {
+	if err := h.DB.Scopes(page.Scope).Find(&users).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 		return
 	}
+	users = users[:page.Trim(len(users), func(i int) []interface{} {
+		return []interface{}{users[i].CreatedAt, users[i].ID}
+	})]
 
 	// Build safe response with timestamps
 	var response []UserResponse
@@ -70,12 +69,7 @@ func (h *UsersHandler) ListUsers(c *gin.Context) {
 		})
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"page": page,
-		"limit": limit,
-		"total": total,
-		"users": response,
-	})
+	respondPage(c, page, gin.H{"users": response})
 }
 
 func (h *UsersHandler) GetUser(c *gin.Context) {
@@ -117,7 +111,28 @@ func (h *UsersHandler) DeleteUser(c *gin.Context) {
 		return
 	}
 
-	if err := h.DB.Delete(&models.User{}, "id = ?", userID).Error; err != nil {
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		admins, err := lockAdmins(tx)
+		if err != nil {
+			return err
+		}
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, "id = ?", userID).Error; err != nil {
+			return err
+		}
+		if user.Role == models.RoleAdmin && admins <= 1 {
+			return errLastAdmin
+		}
+		return tx.Delete(&user).Error
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	case errors.Is(err, errLastAdmin):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
 		return
 	}
@@ -128,3 +143,85 @@ func (h *UsersHandler) DeleteUser(c *gin.Context) {
 	})
 	c.Status(http.StatusNoContent)
 }
+
+type UpdateRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+var errLastAdmin = errors.New("cannot remove the last admin")
+
+// lockAdmins locks every admin row and returns how many there are. Demoting
+// or deleting an admin holds these locks until commit, so two admins
+// removed at once can't each see the other still there. Rows are locked in
+// id order, before the target user, so concurrent callers queue instead of
+// deadlocking.
+func lockAdmins(tx *gorm.DB) (int, error) {
+	var ids []uuid.UUID
+	err := tx.Model(&models.User{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("role = ?", models.RoleAdmin).Order("id").Pluck("id", &ids).Error
+	return len(ids), err
+}
+
+// UpdateRole changes a user's role. It takes effect on the user's next
+// request, since AuthMiddleware reads the role from the database.
+func (h *UsersHandler) UpdateRole(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+	var req UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !models.ValidRole(req.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown role", "roles": models.Roles()})
+		return
+	}
+	if me, ok := c.Get("currentUser"); ok && me == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "you cannot change your own role"})
+		return
+	}
+
+	var user models.User
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		admins, err := lockAdmins(tx)
+		if err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, "id = ?", userID).Error; err != nil {
+			return err
+		}
+		if user.Role == models.RoleAdmin && req.Role != models.RoleAdmin && admins <= 1 {
+			return errLastAdmin
+		}
+		return tx.Model(&user).Update("role", req.Role).Error
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	case errors.Is(err, errLastAdmin):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+		return
+	}
+
+	c.JSON(http.StatusOK, UserResponse{
+		ID:         user.ID,
+		Email:      user.Email,
+		Name:       user.Name,
+		Role:       user.Role,
+		IsVerified: user.IsVerified,
+		CreatedAt:  user.CreatedAt,
+		UpdatedAt:  user.UpdatedAt,
+	})
+}
+
+// ListRoles returns each role with the permissions it grants.
+func (h *UsersHandler) ListRoles(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"roles": models.RolePermissions()})
+}
@@ -1,23 +0,0 @@
-package middleware
-
-import (
-	"net/http"
-
-	"github.com/gin-gonic/gin"
-)
-
-func AdminOnly() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		r, exists := c.Get("currentUserRole")
-		if !exists {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
-			return
-		}
-		roleStr, ok := r.(string)
-		if !ok || roleStr != "admin" {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin only"})
-			return
-		}
-		c.Next()
-	}
-}
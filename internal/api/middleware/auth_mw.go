@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"os"
 	"strings"
@@ -10,14 +11,64 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/olamideolayemi/realestate-backend/internal/models"
+	"github.com/olamideolayemi/realestate-backend/internal/services"
 	"github.com/olamideolayemi/realestate-backend/internal/utils"
 )
 
-func AuthMiddleware(db *gorm.DB) gin.HandlerFunc {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "dev_secret"
+// authError is why a request's token was not accepted, with the response
+// AuthMiddleware gives for it.
+type authError struct {
+	status  int
+	message string
+}
+
+func jwtSecret() string {
+	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		return secret
+	}
+	return "dev_secret"
+}
+
+// authenticate checks the bearer token in auth and stores its user, role
+// and session on the context.
+func authenticate(c *gin.Context, db *gorm.DB, secret, auth string) *authError {
+	parts := strings.Split(auth, " ")
+	if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
+		return &authError{http.StatusUnauthorized, "invalid authorization header"}
+	}
+	tokenStr := parts[1]
+	claims, err := utils.ParseAccessToken(tokenStr, secret)
+	if err != nil {
+		return &authError{http.StatusUnauthorized, "invalid token"}
 	}
+	uid := claims.Subject
+	parsed, err := uuid.Parse(uid)
+	if err != nil {
+		return &authError{http.StatusUnauthorized, "invalid token subject"}
+	}
+
+	// load user to ensure still exists (optional)
+	var u models.User
+	if err := db.First(&u, "id = ?", parsed).Error; err != nil {
+		return &authError{http.StatusUnauthorized, "user not found"}
+	}
+
+	// reject tokens whose session was logged out or revoked
+	if err := services.ValidateSession(db, parsed, claims.SessionID); err != nil {
+		if errors.Is(err, services.ErrSessionRevoked) {
+			return &authError{http.StatusUnauthorized, "session expired; please log in again"}
+		}
+		return &authError{http.StatusInternalServerError, "failed to check session"}
+	}
+
+	c.Set("currentUser", parsed)
+	c.Set("currentUserRole", u.Role)
+	c.Set("currentSession", claims.SessionID)
+	return nil
+}
+
+func AuthMiddleware(db *gorm.DB) gin.HandlerFunc {
+	secret := jwtSecret()
 
 	return func(c *gin.Context) {
 		auth := c.GetHeader("Authorization")
@@ -25,33 +76,29 @@ func AuthMiddleware(db *gorm.DB) gin.HandlerFunc {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authorization required"})
 			return
 		}
-		parts := strings.Split(auth, " ")
-		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization header"})
-			return
-		}
-		tokenStr := parts[1]
-		claims, err := utils.ParseJWT(tokenStr, secret)
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
-			return
-		}
-		uid := claims.Subject
-		parsed, err := uuid.Parse(uid)
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token subject"})
+		if err := authenticate(c, db, secret, auth); err != nil {
+			c.AbortWithStatusJSON(err.status, gin.H{"error": err.message})
 			return
 		}
+		c.Next()
+	}
+}
 
-		// load user to ensure still exists (optional)
-		var u models.User
-		if err := db.First(&u, "id = ?", parsed).Error; err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
-			return
-		}
+// OptionalAuth lets requests without an Authorization header through
+// anonymously, so public routes can personalise their response for those
+// that sign in. A token that is present is checked like AuthMiddleware
+// does: an expired or revoked one gets a 401, telling the client to
+// refresh, rather than a quietly anonymous answer.
+func OptionalAuth(db *gorm.DB) gin.HandlerFunc {
+	secret := jwtSecret()
 
-		c.Set("currentUser", parsed)
-		c.Set("currentUserRole", u.Role)
+	return func(c *gin.Context) {
+		if auth := c.GetHeader("Authorization"); auth != "" {
+			if err := authenticate(c, db, secret, auth); err != nil {
+				c.AbortWithStatusJSON(err.status, gin.H{"error": err.message})
+				return
+			}
+		}
 		c.Next()
 	}
 }
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+)
+
+// RequirePermission lets the request through only if the current user's
+// role grants perm. It must run after AuthMiddleware.
+func RequirePermission(perm models.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r, exists := c.Get("currentUserRole")
+		if !exists {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
+		role, ok := r.(string)
+		if !ok || !models.HasPermission(role, perm) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "missing permission " + string(perm)})
+			return
+		}
+		c.Next()
+	}
+}
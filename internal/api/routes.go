@@ -3,36 +3,144 @@ package api
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/olamideolayemi/realestate-backend/internal/api/middleware"
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+	"github.com/olamideolayemi/realestate-backend/internal/ratelimit"
+	"github.com/olamideolayemi/realestate-backend/internal/services"
 )
 
 func RegisterRoutes(r *gin.Engine, deps *Dependencies) {
 	api := r.Group("/api/v1")
 
+	// Uploaded files, when stored on local disk rather than S3/MinIO
+	if local, ok := deps.Blobs.(*services.LocalBlobStore); ok {
+		r.Static(local.URLPrefix, local.Dir)
+	}
+
 	// API Health Check
 	api.GET("/health", deps.HealthHandler.Health)
 
-	// Auth
-	api.POST("/auth/register", deps.AuthHandler.Register)
-	api.POST("/auth/login", deps.AuthHandler.Login)
-	api.POST("/auth/verify", deps.AuthHandler.VerifyEmail)
-	api.POST("/auth/resend-ver", deps.AuthHandler.ResendVerification)
+	// Auth (rate limited per IP; login and code checks also lock out on failures)
+	authLimit := ratelimit.Middleware(deps.AuthLimiter, ratelimit.ByIP)
+	sendLimit := ratelimit.Middleware(deps.OTPSendLimiter, ratelimit.ByIP)
+	api.POST("/auth/register", authLimit, sendLimit, deps.AuthHandler.Register)
+	api.POST("/auth/login", authLimit, deps.AuthHandler.Login)
+	api.POST("/auth/verify", authLimit, deps.AuthHandler.VerifyEmail)
+	api.POST("/auth/resend-ver", authLimit, sendLimit, deps.AuthHandler.ResendVerification)
+	api.POST("/auth/refresh", authLimit, deps.AuthHandler.Refresh)
+	api.POST("/auth/forgot-password", authLimit, sendLimit, deps.AuthHandler.ForgotPassword)
+	api.POST("/auth/reset-password", authLimit, deps.AuthHandler.ResetPassword)
+	api.POST("/auth/logout", middleware.AuthMiddleware(deps.DB), deps.AuthHandler.Logout)
+	api.POST("/auth/logout-all", middleware.AuthMiddleware(deps.DB), deps.AuthHandler.LogoutAll)
+	api.GET("/auth/sessions", middleware.AuthMiddleware(deps.DB), deps.AuthHandler.ListSessions)
+	api.DELETE("/auth/sessions/:id", middleware.AuthMiddleware(deps.DB), deps.AuthHandler.RevokeSession)
 
-	// Properties
+	// Properties (public; a valid token adds favorites, and lets owners and
+	// staff see unpublished listings)
 	props := api.Group("/properties")
-	props.GET("", deps.PropertyHandler.ListProperties)
-	props.GET("/:id", deps.PropertyHandler.GetProperty)
-
-	// Admin routes (protect with auth + admin check)
-	admin := api.Group("/admin", middleware.AuthMiddleware(deps.DB), middleware.AdminOnly())
-	admin.POST("/properties", deps.PropertyHandler.CreateProperty)
-	admin.PATCH("/properties/:id", deps.PropertyHandler.UpdateProperty)
-	admin.DELETE("/properties/:id", deps.PropertyHandler.DeleteProperty)
-	// Users (Admin)
-	admin.GET("/users", deps.UsersHandler.ListUsers)
-	admin.GET("/users/:id", deps.UsersHandler.GetUser)
-	admin.DELETE("/users/:id", deps.UsersHandler.DeleteUser)
+	props.GET("", middleware.OptionalAuth(deps.DB), deps.PropertyHandler.ListProperties)
+	props.GET("/:id", middleware.OptionalAuth(deps.DB), deps.PropertyHandler.GetProperty)
+	props.GET("/:id/availability", middleware.OptionalAuth(deps.DB), deps.PropertyHandler.GetAvailability)
+	props.GET("/:id/calendar.ics", deps.CalendarHandler.ExportCalendar)
+	props.GET("/:id/quote", middleware.OptionalAuth(deps.DB), deps.PropertyHandler.GetQuote)
+	props.POST("/:id/favorite", middleware.AuthMiddleware(deps.DB), deps.FavoriteHandler.AddFavorite)
+	props.DELETE("/:id/favorite", middleware.AuthMiddleware(deps.DB), deps.FavoriteHandler.RemoveFavorite)
+
+	// Staff routes: every route names the permission it needs (see models/role.go)
+	admin := api.Group("/admin", middleware.AuthMiddleware(deps.DB))
+	can := middleware.RequirePermission
+	// creating here publishes at once, so agents list through /agent/properties
+	admin.POST("/properties", can(models.PermPropertyManage), deps.PropertyHandler.CreateProperty)
+	admin.PATCH("/properties/:id", can(models.PermPropertyManage), deps.PropertyHandler.UpdateProperty)
+	admin.DELETE("/properties/:id", can(models.PermPropertyManage), deps.PropertyHandler.DeleteProperty)
+	admin.PUT("/properties/:id/location", can(models.PermPropertyManage), deps.PropertyHandler.SetLocation)
+	admin.DELETE("/properties/:id/location", can(models.PermPropertyManage), deps.PropertyHandler.ClearLocationOverride)
+	// Images
+	admin.POST("/properties/:id/images", can(models.PermPropertyManage), deps.ImageHandler.UploadImages)
+	admin.PUT("/properties/:id/images/order", can(models.PermPropertyManage), deps.ImageHandler.ReorderImages)
+	admin.PUT("/properties/:id/images/:imageId/cover", can(models.PermPropertyManage), deps.ImageHandler.SetCoverImage)
+	admin.DELETE("/properties/:id/images/:imageId", can(models.PermPropertyManage), deps.ImageHandler.DeleteImage)
+	// Blocked dates
+	admin.GET("/properties/:id/blocks", can(models.PermPropertyManage), deps.BlockHandler.ListBlocks)
+	admin.POST("/properties/:id/blocks", can(models.PermPropertyManage), deps.BlockHandler.CreateBlock)
+	admin.PATCH("/properties/:id/blocks/:blockId", can(models.PermPropertyManage), deps.BlockHandler.UpdateBlock)
+	admin.DELETE("/properties/:id/blocks/:blockId", can(models.PermPropertyManage), deps.BlockHandler.DeleteBlock)
+	// Seasonal rates
+	admin.GET("/properties/:id/rates", can(models.PermPropertyManage), deps.RateHandler.ListRates)
+	admin.POST("/properties/:id/rates", can(models.PermPropertyManage), deps.RateHandler.CreateRate)
+	admin.PATCH("/properties/:id/rates/:rateId", can(models.PermPropertyManage), deps.RateHandler.UpdateRate)
+	admin.DELETE("/properties/:id/rates/:rateId", can(models.PermPropertyManage), deps.RateHandler.DeleteRate)
+	// iCalendar sync
+	admin.GET("/properties/:id/calendar", can(models.PermPropertyManage), deps.CalendarHandler.FeedURL)
+	admin.POST("/properties/:id/calendar/import", can(models.PermPropertyManage), deps.CalendarHandler.ImportCalendar)
+	// Exchange rates for display prices
+	admin.GET("/fx-rates", can(models.PermFXWrite), deps.FXHandler.ListRates)
+	admin.PUT("/fx-rates", can(models.PermFXWrite), deps.FXHandler.SetRate)
+	admin.POST("/fx-rates/import", can(models.PermFXWrite), deps.FXHandler.ImportRates)
+	admin.DELETE("/fx-rates/:id", can(models.PermFXWrite), deps.FXHandler.DeleteRate)
+	// Users and roles
+	admin.GET("/roles", can(models.PermUserRead), deps.UsersHandler.ListRoles)
+	admin.GET("/users", can(models.PermUserRead), deps.UsersHandler.ListUsers)
+	admin.GET("/users/:id", can(models.PermUserRead), deps.UsersHandler.GetUser)
+	admin.PATCH("/users/:id/role", can(models.PermUserWrite), deps.UsersHandler.UpdateRole)
+	admin.DELETE("/users/:id", can(models.PermUserWrite), deps.UsersHandler.DeleteUser)
+	// Bookings
+	admin.GET("/bookings", can(models.PermBookingManage), deps.AdminBookingHandler.ListBookings)
+	admin.GET("/bookings/:id", can(models.PermBookingManage), deps.AdminBookingHandler.GetBooking)
+	admin.POST("/bookings/:id/confirm", can(models.PermBookingManage), deps.AdminBookingHandler.ConfirmBooking)
+	admin.POST("/bookings/:id/cancel", can(models.PermBookingManage), deps.AdminBookingHandler.CancelBooking)
+	admin.POST("/bookings/:id/no-show", can(models.PermBookingManage), deps.AdminBookingHandler.MarkNoShow)
+	admin.PATCH("/bookings/:id/dates", can(models.PermBookingManage), deps.AdminBookingHandler.ChangeDates)
+	// Listing approval queue
+	admin.GET("/listings/pending", can(models.PermPropertyManage), deps.ListingReviewHandler.ReviewQueue)
+	admin.POST("/listings/:id/approve", can(models.PermPropertyManage), deps.ListingReviewHandler.ApproveListing)
+	admin.POST("/listings/:id/reject", can(models.PermPropertyManage), deps.ListingReviewHandler.RejectListing)
+
+	// Agents' own listings; ownership is enforced by the handlers
+	agent := api.Group("/agent/properties", middleware.AuthMiddleware(deps.DB), can(models.PermPropertyWrite))
+	agent.GET("", deps.AgentPropertyHandler.ListMyProperties)
+	agent.POST("", deps.AgentPropertyHandler.CreateMyProperty)
+	agent.GET("/:id", deps.AgentPropertyHandler.GetMyProperty)
+	agent.PATCH("/:id", deps.AgentPropertyHandler.UpdateMyProperty)
+	agent.DELETE("/:id", deps.AgentPropertyHandler.DeleteMyProperty)
+	agent.POST("/:id/submit", deps.AgentPropertyHandler.SubmitMyProperty)
+	agent.POST("/:id/withdraw", deps.AgentPropertyHandler.WithdrawMyProperty)
+	agent.POST("/:id/archive", deps.AgentPropertyHandler.ArchiveMyProperty)
+	agent.POST("/:id/images", deps.AgentImageHandler.UploadImages)
+	agent.PUT("/:id/images/order", deps.AgentImageHandler.ReorderImages)
+	agent.PUT("/:id/images/:imageId/cover", deps.AgentImageHandler.SetCoverImage)
+	agent.DELETE("/:id/images/:imageId", deps.AgentImageHandler.DeleteImage)
 
 	// Bookings
 	api.POST("/bookings", middleware.AuthMiddleware(deps.DB), deps.BookingHandler.CreateBooking)
 	api.GET("/bookings", middleware.AuthMiddleware(deps.DB), deps.BookingHandler.ListUserBookings)
+	api.PATCH("/bookings/:id", middleware.AuthMiddleware(deps.DB), deps.BookingHandler.UpdateBooking)
+	api.POST("/bookings/:id/pay", middleware.AuthMiddleware(deps.DB), deps.PaymentHandler.PayBooking)
+	api.POST("/bookings/:id/cancel", middleware.AuthMiddleware(deps.DB), deps.BookingHandler.CancelBooking)
+
+	// Saved searches
+	searches := api.Group("/saved-searches")
+	searches.GET("/unsubscribe", deps.SavedSearchHandler.Unsubscribe)
+	searches.POST("/unsubscribe", deps.SavedSearchHandler.Unsubscribe)
+	searches.Use(middleware.AuthMiddleware(deps.DB))
+	searches.GET("", deps.SavedSearchHandler.ListSavedSearches)
+	searches.POST("", deps.SavedSearchHandler.CreateSavedSearch)
+	searches.PATCH("/:id", deps.SavedSearchHandler.UpdateSavedSearch)
+	searches.DELETE("/:id", deps.SavedSearchHandler.DeleteSavedSearch)
+
+	// Favorites and wishlists; shared wishlists need only the link's token
+	api.GET("/wishlists/shared/:token", deps.FavoriteHandler.GetSharedWishlist)
+	me := api.Group("/me", middleware.AuthMiddleware(deps.DB))
+	me.GET("/favorites", deps.FavoriteHandler.ListFavorites)
+	me.GET("/wishlists", deps.FavoriteHandler.ListWishlists)
+	me.POST("/wishlists", deps.FavoriteHandler.CreateWishlist)
+	me.GET("/wishlists/:id", deps.FavoriteHandler.GetWishlist)
+	me.PATCH("/wishlists/:id", deps.FavoriteHandler.RenameWishlist)
+	me.DELETE("/wishlists/:id", deps.FavoriteHandler.DeleteWishlist)
+	me.PUT("/wishlists/:id/properties/:propertyId", deps.FavoriteHandler.AddToWishlist)
+	me.DELETE("/wishlists/:id/properties/:propertyId", deps.FavoriteHandler.RemoveFromWishlist)
+	me.POST("/wishlists/:id/share", deps.FavoriteHandler.ShareWishlist)
+	me.DELETE("/wishlists/:id/share", deps.FavoriteHandler.UnshareWishlist)
+
+	// Payments
+	api.POST("/payments/webhook", deps.PaymentHandler.Webhook)
 }
@@ -0,0 +1,218 @@
+// Package imaging decodes, orients, resizes and re-encodes photos using only
+// the standard library. Re-encoding is also how metadata such as EXIF GPS
+// tags is removed: nothing but pixels survives a round trip.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+)
+
+// MaxPixels bounds the decoded size of an upload so a small file can't
+// expand into gigabytes of pixels.
+const MaxPixels = 50_000_000
+
+// JPEGQuality is used for every JPEG written.
+const JPEGQuality = 82
+
+var (
+	ErrUnsupported   = errors.New("unsupported image format")
+	ErrTooManyPixels = errors.New("image dimensions are too large")
+)
+
+// Format is an encoding this package reads and writes.
+type Format string
+
+const (
+	JPEG Format = "jpeg"
+	PNG  Format = "png"
+	GIF  Format = "gif" // read only; written as PNG
+)
+
+// ContentType is the MIME type of images encoded in f.
+func (f Format) ContentType() string {
+	if f == JPEG {
+		return "image/jpeg"
+	}
+	return "image/png"
+}
+
+// Ext is the file extension of images encoded in f.
+func (f Format) Ext() string {
+	if f == JPEG {
+		return ".jpg"
+	}
+	return ".png"
+}
+
+// Decode reads a JPEG, PNG or GIF (first frame) and returns it upright,
+// applying the EXIF orientation of JPEGs. The returned format is the one
+// to write variants in: JPEG stays JPEG, the rest become PNG.
+func Decode(data []byte) (*image.RGBA, Format, error) {
+	cfg, name, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", ErrUnsupported
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || int64(cfg.Width)*int64(cfg.Height) > MaxPixels {
+		return nil, "", ErrTooManyPixels
+	}
+
+	var src image.Image
+	var format Format
+	switch name {
+	case "jpeg":
+		src, err = jpeg.Decode(bytes.NewReader(data))
+		format = JPEG
+	case "png":
+		src, err = png.Decode(bytes.NewReader(data))
+		format = PNG
+	case "gif":
+		src, err = gif.Decode(bytes.NewReader(data))
+		format = PNG
+	default:
+		return nil, "", ErrUnsupported
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	b := src.Bounds()
+	img := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(img, img.Bounds(), src, b.Min, draw.Src)
+	if format == JPEG {
+		img = orient(img, jpegOrientation(data))
+	}
+	return img, format, nil
+}
+
+// Encode writes img in format f.
+func Encode(img image.Image, f Format) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if f == JPEG {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: JPEGQuality})
+	} else {
+		err = (&png.Encoder{CompressionLevel: png.BestCompression}).Encode(&buf, img)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Fit scales img down so neither side exceeds max, keeping its aspect
+// ratio. Smaller images are returned unchanged.
+func Fit(img *image.RGBA, max int) *image.RGBA {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	if w <= max && h <= max {
+		return img
+	}
+	if w >= h {
+		h = int(float64(h)*float64(max)/float64(w) + 0.5)
+		w = max
+	} else {
+		w = int(float64(w)*float64(max)/float64(h) + 0.5)
+		h = max
+	}
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+	return Resize(img, w, h)
+}
+
+// span is the run of source pixels that averages into one destination
+// pixel, with each one's share.
+type span struct {
+	start   int
+	weights []float32
+}
+
+// areaSpans maps src pixels onto dst pixels by area coverage, which gives
+// clean downscales without aliasing.
+func areaSpans(src, dst int) []span {
+	scale := float64(src) / float64(dst)
+	spans := make([]span, dst)
+	for i := range spans {
+		lo, hi := float64(i)*scale, float64(i+1)*scale
+		first := int(lo)
+		last := int(hi)
+		if float64(last) == hi {
+			last--
+		}
+		if last >= src {
+			last = src - 1
+		}
+		ws := make([]float32, last-first+1)
+		for j := first; j <= last; j++ {
+			a, b := float64(j), float64(j+1)
+			if a < lo {
+				a = lo
+			}
+			if b > hi {
+				b = hi
+			}
+			ws[j-first] = float32((b - a) / scale)
+		}
+		spans[i] = span{start: first, weights: ws}
+	}
+	return spans
+}
+
+// Resize scales img to w×h by area averaging. It is meant for shrinking;
+// enlarging works but only repeats pixels.
+func Resize(img *image.RGBA, w, h int) *image.RGBA {
+	sw, sh := img.Bounds().Dx(), img.Bounds().Dy()
+	xs, ys := areaSpans(sw, w), areaSpans(sh, h)
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	row := make([]float32, sw*4)
+
+	for y, ySpan := range ys {
+		// average the source rows under this destination row
+		for i := range row {
+			row[i] = 0
+		}
+		for k, wt := range ySpan.weights {
+			off := (ySpan.start + k) * img.Stride
+			src := img.Pix[off : off+sw*4]
+			for i, v := range src {
+				row[i] += float32(v) * wt
+			}
+		}
+		// then across the columns
+		out := dst.Pix[y*dst.Stride : y*dst.Stride+w*4]
+		for x, xSpan := range xs {
+			var r, g, b, a float32
+			for k, wt := range xSpan.weights {
+				i := (xSpan.start + k) * 4
+				r += row[i] * wt
+				g += row[i+1] * wt
+				b += row[i+2] * wt
+				a += row[i+3] * wt
+			}
+			out[x*4] = clamp8(r)
+			out[x*4+1] = clamp8(g)
+			out[x*4+2] = clamp8(b)
+			out[x*4+3] = clamp8(a)
+		}
+	}
+	return dst
+}
+
+func clamp8(v float32) uint8 {
+	v += 0.5
+	if v <= 0 {
+		return 0
+	}
+	if v >= 255 {
+		return 255
+	}
+	return uint8(v)
+}
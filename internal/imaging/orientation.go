@@ -0,0 +1,111 @@
+package imaging
+
+import (
+	"encoding/binary"
+	"image"
+)
+
+// jpegOrientation returns the EXIF Orientation tag (1-8) of a JPEG, or 1
+// when it has none. Cameras store sideways photos unrotated and rely on this
+// tag, so it must be applied before the metadata is thrown away.
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	i := 2
+	for i+4 <= len(data) {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		if marker == 0xDA || marker == 0xD9 { // image data or end: no more headers
+			return 1
+		}
+		size := int(binary.BigEndian.Uint16(data[i+2:]))
+		if size < 2 || i+2+size > len(data) {
+			return 1
+		}
+		seg := data[i+4 : i+2+size]
+		if marker == 0xE1 && len(seg) > 6 && string(seg[:6]) == "Exif\x00\x00" {
+			return tiffOrientation(seg[6:])
+		}
+		i += 2 + size
+	}
+	return 1
+}
+
+// tiffOrientation reads tag 0x0112 from IFD0 of an EXIF TIFF block.
+func tiffOrientation(t []byte) int {
+	if len(t) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(t[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(t[4:]))
+	if ifd < 8 || ifd+2 > len(t) {
+		return 1
+	}
+	n := int(order.Uint16(t[ifd:]))
+	for k := 0; k < n; k++ {
+		e := ifd + 2 + k*12
+		if e+12 > len(t) {
+			return 1
+		}
+		if order.Uint16(t[e:]) != 0x0112 {
+			continue
+		}
+		if order.Uint16(t[e+2:]) != 3 { // SHORT
+			return 1
+		}
+		if v := int(order.Uint16(t[e+8:])); v >= 1 && v <= 8 {
+			return v
+		}
+		return 1
+	}
+	return 1
+}
+
+// orient turns img upright for an EXIF orientation value.
+func orient(img *image.RGBA, orientation int) *image.RGBA {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // mirrored
+				sx, sy = w-1-x, y
+			case 3: // upside down
+				sx, sy = w-1-x, h-1-y
+			case 4: // mirrored upside down
+				sx, sy = x, h-1-y
+			case 5: // mirrored, rotated
+				sx, sy = y, x
+			case 6: // needs a quarter turn clockwise
+				sx, sy = y, h-1-x
+			case 7:
+				sx, sy = w-1-y, h-1-x
+			case 8: // needs a quarter turn anticlockwise
+				sx, sy = w-1-y, x
+			}
+			si := sy*img.Stride + sx*4
+			di := y*dst.Stride + x*4
+			copy(dst.Pix[di:di+4], img.Pix[si:si+4])
+		}
+	}
+	return dst
+}
@@ -4,19 +4,47 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
+// ValidBookingStatus reports whether s is a booking status.
+func ValidBookingStatus(s string) bool {
+	switch s {
+	case "pending", "confirmed", "cancelled", "no_show":
+		return true
+	}
+	return false
+}
+
+// Booking is a guest's stay. It is charged in Currency; DisplayCurrency,
+// FXRate and DisplayTotal snapshot the conversion the guest was shown.
 type Booking struct {
-	ID          uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
-	PropertyID  uuid.UUID `gorm:"type:uuid;index" json:"property_id"`
-	UserID      *uuid.UUID `gorm:"type:uuid" json:"user_id"`
-	Checkin     time.Time `gorm:"type:date" json:"checkin"`
-	Checkout    time.Time `gorm:"type:date" json:"checkout"`
-	Nights      int       `json:"nights"`
-	Guests      int       `json:"guests"`
-	TotalAmount float64   `json:"total_amount"`
-	Status      string    `gorm:"default:pending" json:"status"` // pending|confirmed|cancelled
-	PaymentRef  string    `json:"payment_ref"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID                 uuid.UUID         `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	PropertyID         uuid.UUID         `gorm:"type:uuid;index" json:"property_id"`
+	UserID             *uuid.UUID        `gorm:"type:uuid" json:"user_id"`
+	Checkin            time.Time         `gorm:"type:date" json:"checkin"`
+	Checkout           time.Time         `gorm:"type:date" json:"checkout"`
+	Nights             int               `json:"nights"`
+	Guests             int               `json:"guests"`
+	TotalAmount        Money             `gorm:"column:total_amount_minor" json:"total_amount"`
+	Currency           string            `gorm:"default:NGN" json:"currency"`
+	DisplayCurrency    string            `json:"display_currency,omitempty"`
+	FXRate             *string           `gorm:"type:numeric(24,12)" json:"fx_rate,omitempty"`
+	FXRateAt           *time.Time        `json:"fx_rate_at,omitempty"`
+	DisplayTotal       *Money            `gorm:"column:display_total_minor" json:"display_total,omitempty"`
+	Status             string            `gorm:"default:pending" json:"status"` // pending|confirmed|cancelled|no_show
+	PaymentRef         string            `json:"payment_ref"`
+	CancellationReason string            `json:"cancellation_reason,omitempty"`
+	CancelledAt        *time.Time        `json:"cancelled_at,omitempty"`
+	LineItems          []BookingLineItem `gorm:"foreignKey:BookingID" json:"line_items,omitempty"`
+	CreatedAt          time.Time         `json:"created_at"`
+	UpdatedAt          time.Time         `json:"updated_at"`
+}
+
+func (b *Booking) AfterFind(*gorm.DB) error {
+	setCurrency(b.Currency, &b.TotalAmount)
+	if b.DisplayTotal != nil {
+		setCurrency(b.DisplayCurrency, b.DisplayTotal)
+	}
+	return nil
 }
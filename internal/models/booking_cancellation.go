@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// BookingCancellation records who cancelled a booking and what they were refunded.
+type BookingCancellation struct {
+	ID                uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	BookingID         uuid.UUID  `gorm:"type:uuid;uniqueIndex" json:"booking_id"`
+	CancelledBy       *uuid.UUID `gorm:"type:uuid" json:"cancelled_by"`
+	Reason            string     `json:"reason"`
+	Policy            string     `json:"policy"` // flexible|moderate|strict
+	DaysBeforeCheckin int        `json:"days_before_checkin"`
+	RefundPercent     int        `json:"refund_percent"`
+	RefundAmount      Money      `gorm:"column:refund_amount_minor" json:"refund_amount"`
+	Currency          string     `json:"currency"`
+	RefundStatus      string     `gorm:"default:none" json:"refund_status"` // none|pending|processed|failed
+	RefundRef         string     `json:"refund_ref"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+func (c *BookingCancellation) AfterFind(*gorm.DB) error {
+	setCurrency(c.Currency, &c.RefundAmount)
+	return nil
+}
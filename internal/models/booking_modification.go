@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// BookingModification records a change to a booking's dates or guests and
+// the price difference it created. A positive Difference is owed by the
+// guest, a negative one is refundable to them.
+type BookingModification struct {
+	ID          uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	BookingID   uuid.UUID  `gorm:"type:uuid;index" json:"booking_id"`
+	ChangedBy   *uuid.UUID `gorm:"type:uuid" json:"changed_by"`
+	OldCheckin  time.Time  `gorm:"type:date" json:"old_checkin"`
+	OldCheckout time.Time  `gorm:"type:date" json:"old_checkout"`
+	OldGuests   int        `json:"old_guests"`
+	NewCheckin  time.Time  `gorm:"type:date" json:"new_checkin"`
+	NewCheckout time.Time  `gorm:"type:date" json:"new_checkout"`
+	NewGuests   int        `json:"new_guests"`
+	OldTotal    Money      `gorm:"column:old_total_minor" json:"old_total"`
+	NewTotal    Money      `gorm:"column:new_total_minor" json:"new_total"`
+	Difference  Money      `gorm:"column:difference_minor" json:"difference"`
+	Currency    string     `json:"currency"`
+	Settlement  string     `json:"settlement"` // none|owed|refundable
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func (m *BookingModification) AfterFind(*gorm.DB) error {
+	setCurrency(m.Currency, &m.OldTotal, &m.NewTotal, &m.Difference)
+	return nil
+}
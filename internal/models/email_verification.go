@@ -6,10 +6,18 @@ import (
 	"gorm.io/gorm"
 )
 
+// One-time code purposes. Each email has at most one live code per purpose.
+const (
+	PurposeVerifyEmail   = "verify_email"
+	PurposePasswordReset = "password_reset"
+)
+
 type EmailVerification struct {
-	ID        uint           `gorm:"primaryKey"`
-	Email     string         `gorm:"uniqueIndex"`
-	Code      string         `gorm:"size:6"`
+	ID        uint   `gorm:"primaryKey"`
+	Email     string `gorm:"uniqueIndex:idx_email_verifications_email_purpose"`
+	Purpose   string `gorm:"uniqueIndex:idx_email_verifications_email_purpose;default:verify_email"`
+	Code      string `gorm:"size:6"`
+	Attempts  int    `gorm:"default:0"` // wrong guesses so far
 	ExpiresAt time.Time
 	CreatedAt time.Time
 	UpdatedAt time.Time
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserFavorite is a property a user has shortlisted.
+type UserFavorite struct {
+	ID         uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID     uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_user_favorites_user_property" json:"user_id"`
+	PropertyID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_user_favorites_user_property;index" json:"property_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Wishlist is a user's named collection of properties. Setting ShareToken
+// lets anyone with the link view it.
+type Wishlist struct {
+	ID         uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID     uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	Name       string    `gorm:"not null" json:"name"`
+	ShareToken *string   `gorm:"uniqueIndex" json:"share_token,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// WishlistItem is one property in a wishlist.
+type WishlistItem struct {
+	WishlistID uuid.UUID `gorm:"type:uuid;primaryKey" json:"wishlist_id"`
+	PropertyID uuid.UUID `gorm:"type:uuid;primaryKey;index" json:"property_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
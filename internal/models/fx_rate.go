@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FXRate is the number of QuoteCurrency units one BaseCurrency unit buys.
+// Rates are maintained by staff and only used to display prices; bookings are
+// always charged in the property's own currency.
+type FXRate struct {
+	ID            uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	BaseCurrency  string     `gorm:"size:3;uniqueIndex:idx_fx_rates_pair" json:"base_currency"`
+	QuoteCurrency string     `gorm:"size:3;uniqueIndex:idx_fx_rates_pair" json:"quote_currency"`
+	Rate          string     `gorm:"type:numeric(24,12)" json:"rate"` // exact decimal
+	Source        string     `gorm:"default:manual" json:"source"`    // manual|csv
+	UpdatedBy     *uuid.UUID `gorm:"type:uuid" json:"updated_by"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
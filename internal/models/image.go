@@ -4,12 +4,64 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Image variant names, smallest first.
+const (
+	ImageThumbnail = "thumbnail"
+	ImageCard      = "card"
+	ImageFull      = "full"
 )
 
 type PropertyImage struct {
-	ID         uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
-	PropertyID uuid.UUID `gorm:"type:uuid;index" json:"property_id"`
-	URL        string    `json:"url"`
-	AltText    string    `json:"alt_text"`
-	CreatedAt  time.Time `json:"created_at"`
+	ID           uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	PropertyID   uuid.UUID `gorm:"type:uuid;index" json:"property_id"`
+	URL          string    `json:"url"` // the full variant
+	ThumbnailURL string    `json:"-"`
+	CardURL      string    `json:"-"`
+	AltText      string    `json:"alt_text"`
+	StorageKey   string    `json:"-"` // blob key of the full variant; empty for external URLs
+	ThumbnailKey string    `json:"-"`
+	CardKey      string    `json:"-"`
+	ContentType  string    `json:"content_type,omitempty"`
+	Size         int64     `json:"size,omitempty"`   // bytes of the full variant
+	Width        int       `json:"width,omitempty"`  // pixels of the full variant
+	Height       int       `json:"height,omitempty"` // pixels of the full variant
+	Position     int       `gorm:"default:0" json:"position"`
+	IsCover      bool      `gorm:"default:false" json:"is_cover"`
+	CreatedAt    time.Time `json:"created_at"`
+
+	// Variants maps variant name to URL. Images without resized copies
+	// point every variant at URL.
+	Variants map[string]string `gorm:"-" json:"variants"`
+}
+
+// AfterFind fills in Variants.
+func (img *PropertyImage) AfterFind(*gorm.DB) error {
+	img.SetVariants()
+	return nil
+}
+
+// SetVariants builds Variants from the stored URLs.
+func (img *PropertyImage) SetVariants() {
+	thumb, card := img.ThumbnailURL, img.CardURL
+	if card == "" {
+		card = img.URL
+	}
+	if thumb == "" {
+		thumb = card
+	}
+	img.Variants = map[string]string{ImageThumbnail: thumb, ImageCard: card, ImageFull: img.URL}
+}
+
+// BlobKeys lists the stored files behind the image.
+func (img *PropertyImage) BlobKeys() []string {
+	var keys []string
+	for _, k := range []string{img.StorageKey, img.CardKey, img.ThumbnailKey} {
+		if k != "" {
+			keys = append(keys, k)
+		}
+	}
+	return keys
 }
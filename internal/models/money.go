@@ -0,0 +1,281 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Money is an exact amount in a currency's minor unit (kobo, cents, pence).
+//
+// In the database a Money is stored as a BIGINT of minor units; its currency
+// lives in the owning row's currency column and is filled in by that model's
+// AfterFind hook.
+type Money struct {
+	Amount   int64  // minor units
+	Currency string // ISO 4217 code
+}
+
+var (
+	ErrInvalidAmount    = errors.New("invalid money amount")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	ErrMoneyOverflow    = errors.New("money amount out of range")
+)
+
+// currencyExponents lists currencies whose minor unit isn't 1/100.
+var currencyExponents = map[string]int{
+	"JPY": 0,
+	"KRW": 0,
+	"XOF": 0,
+	"XAF": 0,
+	"KWD": 3,
+	"BHD": 3,
+}
+
+// CurrencyExponent returns the number of decimal places of currency's minor unit.
+func CurrencyExponent(currency string) int {
+	if e, ok := currencyExponents[strings.ToUpper(currency)]; ok {
+		return e
+	}
+	return 2
+}
+
+// NewMoney returns amount minor units of currency.
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// ParseMoney parses a decimal amount in major units, e.g. "15000" or
+// "150.25", without going through floating point.
+func ParseMoney(s, currency string) (Money, error) {
+	s = strings.TrimSpace(s)
+	exp := CurrencyExponent(currency)
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return Money{}, ErrInvalidAmount
+	}
+	if len(frac) > exp {
+		// allow trailing zeros beyond the minor unit, nothing else
+		if strings.Trim(frac[exp:], "0") != "" {
+			return Money{}, fmt.Errorf("%w: more than %d decimal places", ErrInvalidAmount, exp)
+		}
+		frac = frac[:exp]
+	}
+	frac += strings.Repeat("0", exp-len(frac))
+	if whole == "" {
+		whole = "0"
+	}
+	digits := whole + frac
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return Money{}, ErrInvalidAmount
+		}
+	}
+	n, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Money{}, ErrInvalidAmount
+	}
+	if neg {
+		n = -n
+	}
+	return NewMoney(n, currency), nil
+}
+
+// Decimal formats the amount in major units, e.g. "150.25".
+func (m Money) Decimal() string {
+	exp := CurrencyExponent(m.Currency)
+	sign := ""
+	n := m.Amount
+	if n < 0 {
+		sign = "-"
+		n = -n
+	}
+	if exp == 0 {
+		return sign + strconv.FormatInt(n, 10)
+	}
+	s := fmt.Sprintf("%0*d", exp+1, n)
+	return sign + s[:len(s)-exp] + "." + s[len(s)-exp:]
+}
+
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+func (m Money) IsZero() bool     { return m.Amount == 0 }
+func (m Money) IsPositive() bool { return m.Amount > 0 }
+func (m Money) IsNegative() bool { return m.Amount < 0 }
+func (m Money) Neg() Money       { return Money{Amount: -m.Amount, Currency: m.Currency} }
+
+// Add returns m+o. Amounts in different currencies can't be added; a zero
+// Money without a currency takes the other's currency.
+func (m Money) Add(o Money) (Money, error) {
+	cur, err := m.common(o)
+	if err != nil {
+		return Money{}, err
+	}
+	sum := m.Amount + o.Amount
+	if (o.Amount > 0 && sum < m.Amount) || (o.Amount < 0 && sum > m.Amount) {
+		return Money{}, ErrMoneyOverflow
+	}
+	return Money{Amount: sum, Currency: cur}, nil
+}
+
+// Sub returns m-o, with the same currency rules as Add.
+func (m Money) Sub(o Money) (Money, error) {
+	if o.Amount == math.MinInt64 {
+		return Money{}, ErrMoneyOverflow
+	}
+	return m.Add(o.Neg())
+}
+
+// Mul returns m*n.
+func (m Money) Mul(n int64) (Money, error) {
+	return m.Ratio(n, 1)
+}
+
+// Percent returns p percent of m, rounded half away from zero to the minor unit.
+func (m Money) Percent(p int) (Money, error) {
+	return m.Ratio(int64(p), 100)
+}
+
+// Ratio returns m*num/den rounded half away from zero to the minor unit. The
+// product is exact, so only a result that doesn't fit is an overflow.
+func (m Money) Ratio(num, den int64) (Money, error) {
+	if den == 0 {
+		return Money{}, ErrInvalidAmount
+	}
+	prod := new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(num))
+	d := big.NewInt(den)
+	q, r := new(big.Int).QuoRem(prod, d, new(big.Int))
+	if r.Sign() != 0 && new(big.Int).Lsh(r.Abs(r), 1).Cmp(d.Abs(d)) >= 0 {
+		if prod.Sign() != big.NewInt(den).Sign() {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	if !q.IsInt64() {
+		return Money{}, ErrMoneyOverflow
+	}
+	return Money{Amount: q.Int64(), Currency: m.Currency}, nil
+}
+
+// Float returns the amount in major units. Use it only for display or for
+// systems that require a float, never for arithmetic.
+func (m Money) Float() float64 {
+	return float64(m.Amount) / math.Pow10(CurrencyExponent(m.Currency))
+}
+
+func (m Money) common(o Money) (string, error) {
+	switch {
+	case m.Currency == o.Currency:
+		return m.Currency, nil
+	case m.Currency == "" && m.Amount == 0:
+		return o.Currency, nil
+	case o.Currency == "" && o.Amount == 0:
+		return m.Currency, nil
+	}
+	return "", fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+}
+
+type moneyJSON struct {
+	Amount      json.RawMessage `json:"amount"`
+	AmountMinor *int64          `json:"amount_minor"`
+	Currency    string          `json:"currency"`
+}
+
+// MarshalJSON writes {"amount": "150.25", "amount_minor": 15025, "currency": "NGN"}.
+// The decimal is a string so clients never see float rounding.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount      string `json:"amount"`
+		AmountMinor int64  `json:"amount_minor"`
+		Currency    string `json:"currency"`
+	}{m.Decimal(), m.Amount, m.Currency})
+}
+
+// UnmarshalJSON accepts the object written by MarshalJSON (either amount or
+// amount_minor may be given), or a bare number or numeric string in major
+// units whose currency is set by the caller afterwards.
+func (m *Money) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	if len(b) > 0 && b[0] == '{' {
+		var v moneyJSON
+		if err := json.Unmarshal(b, &v); err != nil {
+			return err
+		}
+		if v.AmountMinor != nil {
+			*m = NewMoney(*v.AmountMinor, v.Currency)
+			return nil
+		}
+		parsed, err := ParseMoney(unquote(v.Amount), v.Currency)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	}
+	parsed, err := ParseMoney(unquote(b), m.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+func unquote(b []byte) string {
+	return strings.Trim(string(b), `"`)
+}
+
+// Value stores the amount as minor units.
+func (m Money) Value() (driver.Value, error) {
+	return m.Amount, nil
+}
+
+// Scan reads minor units. The currency is left for the owning model to fill.
+func (m *Money) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		m.Amount = 0
+	case int64:
+		m.Amount = v
+	case int32:
+		m.Amount = int64(v)
+	case []byte:
+		n, err := strconv.ParseInt(string(v), 10, 64)
+		if err != nil {
+			return fmt.Errorf("scan money: %w", err)
+		}
+		m.Amount = n
+	case string:
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("scan money: %w", err)
+		}
+		m.Amount = n
+	default:
+		return fmt.Errorf("scan money: unsupported type %T", src)
+	}
+	return nil
+}
+
+// GormDataType makes AutoMigrate create Money columns as BIGINT.
+func (Money) GormDataType() string {
+	return "bigint"
+}
+
+// setCurrency stamps currency on each of ms, used by AfterFind hooks.
+func setCurrency(currency string, ms ...*Money) {
+	for _, m := range ms {
+		m.Currency = currency
+	}
+}
@@ -0,0 +1,152 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	for _, tc := range []struct {
+		in, currency string
+		want         int64
+	}{
+		{"15000", "NGN", 1500000},
+		{"150.25", "NGN", 15025},
+		{"150.250", "NGN", 15025}, // trailing zeros past the minor unit
+		{" 0.5 ", "NGN", 50},
+		{".5", "NGN", 50},
+		{"7.", "NGN", 700},
+		{"+2", "USD", 200},
+		{"-3.5", "USD", -350},
+		{"1500", "JPY", 1500},
+		{"1.234", "KWD", 1234},
+		{"92233720368547758.07", "NGN", math.MaxInt64},
+	} {
+		got, err := ParseMoney(tc.in, tc.currency)
+		if err != nil || got != NewMoney(tc.want, tc.currency) {
+			t.Errorf("ParseMoney(%q, %s) = %v, %v; want %d", tc.in, tc.currency, got, err, tc.want)
+		}
+	}
+	for _, tc := range []struct{ in, currency string }{
+		{"", "NGN"},
+		{"-", "NGN"},
+		{".", "NGN"},
+		{"abc", "NGN"},
+		{"1,000", "NGN"},
+		{"1e5", "NGN"},
+		{"1.2.3", "NGN"},
+		{"150.255", "NGN"},
+		{"1500.5", "JPY"},
+		{"92233720368547758.08", "NGN"},
+	} {
+		if got, err := ParseMoney(tc.in, tc.currency); !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("ParseMoney(%q, %s) = %v, %v; want ErrInvalidAmount", tc.in, tc.currency, got, err)
+		}
+	}
+}
+
+func TestMoneyDecimal(t *testing.T) {
+	for _, tc := range []struct {
+		m    Money
+		want string
+	}{
+		{NewMoney(15025, "NGN"), "150.25"},
+		{NewMoney(5, "NGN"), "0.05"},
+		{NewMoney(-350, "USD"), "-3.50"},
+		{NewMoney(1500, "JPY"), "1500"},
+		{NewMoney(1234, "KWD"), "1.234"},
+	} {
+		if got := tc.m.Decimal(); got != tc.want {
+			t.Errorf("%d %s: Decimal() = %q; want %q", tc.m.Amount, tc.m.Currency, got, tc.want)
+		}
+		back, err := ParseMoney(tc.want, tc.m.Currency)
+		if err != nil || back != tc.m {
+			t.Errorf("ParseMoney(%q) = %v, %v; want %v", tc.want, back, err, tc.m)
+		}
+	}
+}
+
+func TestMoneyRatioRoundsHalfAwayFromZero(t *testing.T) {
+	for _, tc := range []struct {
+		amount, num, den, want int64
+	}{
+		{5, 1, 2, 3},
+		{-5, 1, 2, -3},
+		{5, -1, 2, -3},
+		{5, 1, -2, -3},
+		{4, 1, 3, 1}, // 1.33
+		{5, 1, 3, 2}, // 1.67
+		{-4, 1, 3, -1},
+		{233331, 5, 100, 11667}, // 11666.55
+		{233329, 5, 100, 11666}, // 11666.45
+		{0, 7, 9, 0},
+		{math.MaxInt64, 2, 2, math.MaxInt64}, // exact even though the product isn't an int64
+	} {
+		got, err := NewMoney(tc.amount, "NGN").Ratio(tc.num, tc.den)
+		if err != nil || got != NewMoney(tc.want, "NGN") {
+			t.Errorf("%d * %d / %d = %v, %v; want %d", tc.amount, tc.num, tc.den, got, err, tc.want)
+		}
+	}
+	if got, err := NewMoney(3333, "NGN").Percent(115); err != nil || got.Amount != 3833 {
+		t.Errorf("115%% of 3333 = %v, %v; want 3833", got, err)
+	}
+	if _, err := NewMoney(1, "NGN").Ratio(1, 0); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("Ratio by zero: err = %v; want ErrInvalidAmount", err)
+	}
+}
+
+func TestMoneyOverflow(t *testing.T) {
+	max := NewMoney(math.MaxInt64, "NGN")
+	min := NewMoney(math.MinInt64, "NGN")
+	one := NewMoney(1, "NGN")
+	for name, f := range map[string]func() (Money, error){
+		"Add":          func() (Money, error) { return max.Add(one) },
+		"Add negative": func() (Money, error) { return min.Add(one.Neg()) },
+		"Sub":          func() (Money, error) { return min.Sub(one) },
+		"Sub MinInt64": func() (Money, error) { return NewMoney(0, "NGN").Sub(min) },
+		"Mul":          func() (Money, error) { return max.Mul(2) },
+		"Mul negative": func() (Money, error) { return max.Mul(-2) },
+		"Percent":      func() (Money, error) { return max.Percent(101) },
+		"Ratio":        func() (Money, error) { return max.Ratio(3, 2) },
+	} {
+		if got, err := f(); !errors.Is(err, ErrMoneyOverflow) {
+			t.Errorf("%s = %v, %v; want ErrMoneyOverflow", name, got, err)
+		}
+	}
+	if got, err := max.Add(one.Neg()); err != nil || got.Amount != math.MaxInt64-1 {
+		t.Errorf("MaxInt64 - 1 = %v, %v", got, err)
+	}
+	if got, err := NewMoney(-3, "NGN").Mul(-4); err != nil || got.Amount != 12 {
+		t.Errorf("-3 * -4 = %v, %v; want 12", got, err)
+	}
+}
+
+func TestMoneyAddCurrencies(t *testing.T) {
+	if _, err := NewMoney(100, "NGN").Add(NewMoney(100, "USD")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("NGN + USD: err = %v; want ErrCurrencyMismatch", err)
+	}
+	// a zero amount without a currency takes the other's
+	if got, err := (Money{}).Add(NewMoney(100, "USD")); err != nil || got != NewMoney(100, "USD") {
+		t.Errorf("0 + 1.00 USD = %v, %v", got, err)
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	b, err := json.Marshal(NewMoney(15025, "NGN"))
+	if err != nil || string(b) != `{"amount":"150.25","amount_minor":15025,"currency":"NGN"}` {
+		t.Fatalf("Marshal = %s, %v", b, err)
+	}
+	for in, want := range map[string]Money{
+		string(b):                              NewMoney(15025, "NGN"),
+		`{"amount":"150.25","currency":"usd"}`: NewMoney(15025, "USD"),
+		`{"amount":150.25,"currency":"USD"}`:   NewMoney(15025, "USD"),
+		`{"amount_minor":99,"currency":"JPY"}`: NewMoney(99, "JPY"),
+	} {
+		var got Money
+		if err := json.Unmarshal([]byte(in), &got); err != nil || got != want {
+			t.Errorf("Unmarshal(%s) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Payment is one checkout attempt for a booking at a payment gateway.
+type Payment struct {
+	ID               uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	BookingID        uuid.UUID  `gorm:"type:uuid;index" json:"booking_id"`
+	Reference        string     `gorm:"uniqueIndex;not null" json:"reference"`
+	Gateway          string     `json:"gateway"`
+	Amount           Money      `gorm:"column:amount_minor" json:"amount"`
+	Currency         string     `json:"currency"`
+	Status           string     `gorm:"default:initialized" json:"status"` // initialized|stale|success|failed|refund_due|refunded
+	RefundedAmount   Money      `gorm:"column:refunded_amount_minor" json:"refunded_amount"`
+	AuthorizationURL string     `json:"authorization_url"`
+	AccessCode       string     `json:"access_code"`
+	PaidAt           *time.Time `json:"paid_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+func (p *Payment) AfterFind(*gorm.DB) error {
+	setCurrency(p.Currency, &p.Amount, &p.RefundedAmount)
+	return nil
+}
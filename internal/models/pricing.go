@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PropertyRate overrides a property's nightly price for the nights from
+// StartDate up to (not including) EndDate, e.g. for festive seasons. When
+// rates overlap the highest Priority wins.
+type PropertyRate struct {
+	ID           uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	PropertyID   uuid.UUID `gorm:"type:uuid;index" json:"property_id"`
+	Label        string    `json:"label"`
+	StartDate    time.Time `gorm:"type:date" json:"start_date"`
+	EndDate      time.Time `gorm:"type:date" json:"end_date"`
+	NightlyPrice Money     `gorm:"column:nightly_price_minor" json:"nightly_price"`
+	Currency     string    `json:"currency"`
+	Priority     int       `gorm:"default:0" json:"priority"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// BookingLineItem is one priced line of a booking's total.
+type BookingLineItem struct {
+	ID          uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	BookingID   uuid.UUID  `gorm:"type:uuid;index" json:"booking_id"`
+	Kind        string     `json:"kind"` // nightly|discount|cleaning_fee|caution_deposit
+	Description string     `json:"description"`
+	Date        *time.Time `gorm:"type:date" json:"date,omitempty"`
+	Amount      Money      `gorm:"column:amount_minor" json:"amount"`
+	Currency    string     `json:"currency"`
+	Refundable  bool       `json:"refundable"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func (r *PropertyRate) AfterFind(*gorm.DB) error {
+	setCurrency(r.Currency, &r.NightlyPrice)
+	return nil
+}
+
+func (l *BookingLineItem) AfterFind(*gorm.DB) error {
+	setCurrency(l.Currency, &l.Amount)
+	return nil
+}
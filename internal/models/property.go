@@ -4,24 +4,68 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
+// Listing statuses. Only published properties are shown to the public;
+// agents' listings go through pending_review before an admin publishes them.
+const (
+	PropertyDraft         = "draft"
+	PropertyPendingReview = "pending_review"
+	PropertyPublished     = "published"
+	PropertyArchived      = "archived"
+)
+
+// ValidPropertyStatus reports whether s is a listing status.
+func ValidPropertyStatus(s string) bool {
+	switch s {
+	case PropertyDraft, PropertyPendingReview, PropertyPublished, PropertyArchived:
+		return true
+	}
+	return false
+}
+
 type Property struct {
-	ID           uuid.UUID       `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
-	Title        string          `json:"title"`
-	Description  string          `json:"description"`
-	Category     string          `json:"category"` // buy|rent|shortlet
-	Price        float64         `json:"price"`
-	Currency     string          `gorm:"default:NGN" json:"currency"`
-	Address      string          `json:"address"`
-	Area         string          `json:"area"`
-	Bedrooms     int             `json:"bedrooms"`
-	Bathrooms    int             `json:"bathrooms"`
-	Furnished    bool            `json:"furnished"`
-	PartyAllowed bool            `json:"party_allowed"`
-	InstantBook  bool            `json:"instant_book"`
-	OwnerID      *uuid.UUID      `gorm:"type:uuid" json:"owner_id"`
-	Images       []PropertyImage `gorm:"foreignKey:PropertyID" json:"images,omitempty"`
-	CreatedAt    time.Time       `json:"created_at"`
-	UpdatedAt    time.Time       `json:"updated_at"`
+	ID                 uuid.UUID       `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	Title              string          `json:"title"`
+	Description        string          `json:"description"`
+	Category           string          `json:"category"` // buy|rent|shortlet
+	Price              Money           `gorm:"column:price_minor;not null;default:0" json:"price"`
+	Currency           string          `gorm:"default:NGN" json:"currency"`
+	Address            string          `json:"address"`
+	Area               string          `json:"area"`
+	Latitude           *float64        `gorm:"index:idx_properties_location,priority:1" json:"latitude"`
+	Longitude          *float64        `gorm:"index:idx_properties_location,priority:2" json:"longitude"`
+	GeocodeOverride    bool            `gorm:"not null;default:false" json:"geocode_override"` // an admin placed the pin; owner edits keep it
+	Bedrooms           int             `json:"bedrooms"`
+	Bathrooms          int             `json:"bathrooms"`
+	Furnished          bool            `json:"furnished"`
+	PartyAllowed       bool            `json:"party_allowed"`
+	InstantBook        bool            `json:"instant_book"`
+	MinNights          int             `gorm:"default:1" json:"min_nights"`
+	MaxNights          int             `gorm:"default:0" json:"max_nights"`                                   // 0 means no limit
+	CancellationPolicy string          `gorm:"default:moderate" json:"cancellation_policy"`                   // flexible|moderate|strict
+	WeekendUplift      int             `gorm:"default:0" json:"weekend_uplift"`                               // percent added to Friday and Saturday nights
+	WeeklyDiscount     int             `gorm:"default:0" json:"weekly_discount"`                              // percent off stays of 7+ nights
+	MonthlyDiscount    int             `gorm:"default:0" json:"monthly_discount"`                             // percent off stays of 28+ nights
+	BaseGuests         int             `gorm:"default:0" json:"base_guests"`                                  // guests the nightly price covers; 0 means any number
+	ExtraGuestFee      Money           `gorm:"column:extra_guest_fee_minor;default:0" json:"extra_guest_fee"` // per night for each guest beyond BaseGuests
+	CleaningFee        Money           `gorm:"column:cleaning_fee_minor;default:0" json:"cleaning_fee"`
+	CautionDeposit     Money           `gorm:"column:caution_deposit_minor;default:0" json:"caution_deposit"` // refundable security deposit
+	Rates              []PropertyRate  `gorm:"foreignKey:PropertyID" json:"rates,omitempty"`
+	OwnerID            *uuid.UUID      `gorm:"type:uuid;index" json:"owner_id"`
+	Status             string          `gorm:"default:published;index" json:"status"` // draft|pending_review|published|archived
+	ReviewNote         string          `json:"review_note,omitempty"`                 // why an admin sent the listing back
+	SubmittedAt        *time.Time      `json:"submitted_at,omitempty"`
+	ReviewedBy         *uuid.UUID      `gorm:"type:uuid" json:"reviewed_by,omitempty"`
+	PublishedAt        *time.Time      `json:"published_at,omitempty"`
+	Images             []PropertyImage `gorm:"foreignKey:PropertyID" json:"images,omitempty"`
+	CreatedAt          time.Time       `json:"created_at"`
+	UpdatedAt          time.Time       `json:"updated_at"`
+}
+
+// AfterFind stamps the property's currency on its money fields.
+func (p *Property) AfterFind(*gorm.DB) error {
+	setCurrency(p.Currency, &p.Price, &p.ExtraGuestFee, &p.CleaningFee, &p.CautionDeposit)
+	return nil
 }
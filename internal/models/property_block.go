@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PropertyBlock takes a property's nights off sale without a booking, e.g. for
+// cleaning, owner stays or repairs. Like a booking it covers the nights from
+// StartDate up to (not including) EndDate.
+type PropertyBlock struct {
+	ID          uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	PropertyID  uuid.UUID  `gorm:"type:uuid;index" json:"property_id"`
+	StartDate   time.Time  `gorm:"type:date" json:"start_date"`
+	EndDate     time.Time  `gorm:"type:date" json:"end_date"`
+	Reason      string     `gorm:"default:maintenance" json:"reason"` // cleaning|owner_stay|maintenance|external|other
+	Note        string     `json:"note"`
+	Source      string     `gorm:"default:manual;index" json:"source"` // manual, or the channel an .ics import came from
+	ExternalUID string     `json:"external_uid,omitempty"`
+	CreatedBy   *uuid.UUID `gorm:"type:uuid" json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import "sort"
+
+// User roles.
+const (
+	RoleAdmin = "admin"
+	RoleAgent = "agent"
+	RoleUser  = "user"
+)
+
+// Permission names one thing a role may do, as resource:action.
+type Permission string
+
+const (
+	PermPropertyWrite  Permission = "property:write"  // list new properties
+	PermPropertyManage Permission = "property:manage" // edit or remove any property, its calendar and rates
+	PermBookingManage  Permission = "booking:manage"  // view, confirm, cancel and move any booking
+	PermUserRead       Permission = "user:read"
+	PermUserWrite      Permission = "user:write" // delete users and change roles
+	PermFXWrite        Permission = "fx:write"   // maintain exchange rates
+)
+
+// rolePermissions grants permissions to roles. Plain users act only on their
+// own bookings and account, which needs no permission.
+var rolePermissions = map[string][]Permission{
+	RoleAdmin: {
+		PermPropertyWrite, PermPropertyManage, PermBookingManage,
+		PermUserRead, PermUserWrite, PermFXWrite,
+	},
+	RoleAgent: {PermPropertyWrite},
+	RoleUser:  {},
+}
+
+// ValidRole reports whether role is one of the known roles.
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// HasPermission reports whether role grants p. Unknown roles grant nothing.
+func HasPermission(role string, p Permission) bool {
+	for _, granted := range rolePermissions[role] {
+		if granted == p {
+			return true
+		}
+	}
+	return false
+}
+
+// RolePermissions returns a copy of the role to permissions table.
+func RolePermissions() map[string][]Permission {
+	out := make(map[string][]Permission, len(rolePermissions))
+	for role, perms := range rolePermissions {
+		out[role] = append([]Permission{}, perms...)
+	}
+	return out
+}
+
+// Roles lists the known roles in alphabetical order.
+func Roles() []string {
+	roles := make([]string, 0, len(rolePermissions))
+	for role := range rolePermissions {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return roles
+}
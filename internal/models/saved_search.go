@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// How often a saved search emails its new matches.
+const (
+	AlertInstant = "instant"
+	AlertDaily   = "daily"
+	AlertWeekly  = "weekly"
+)
+
+// ValidAlertFrequency reports whether f is a known frequency.
+func ValidAlertFrequency(f string) bool {
+	switch f {
+	case AlertInstant, AlertDaily, AlertWeekly:
+		return true
+	}
+	return false
+}
+
+// SavedSearch is a user's named set of ListProperties filters. When Alerts
+// is on, listings published after LastCheckedAt that match are emailed to
+// the user at Frequency.
+type SavedSearch struct {
+	ID     uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID uuid.UUID `gorm:"type:uuid;index" json:"user_id"`
+	Name   string    `gorm:"not null" json:"name"`
+	// Query is the ListProperties query string, e.g. "area=Lekki&min_bedrooms=2".
+	Query            string     `gorm:"not null" json:"query"`
+	Frequency        string     `gorm:"not null;default:daily" json:"frequency"` // instant|daily|weekly
+	Alerts           bool       `gorm:"not null;default:true" json:"alerts"`
+	AlertsOffReason  string     `json:"alerts_off_reason,omitempty"` // why the matcher turned Alerts off; editing the search clears it
+	UnsubscribeToken string     `gorm:"uniqueIndex;not null" json:"-"`
+	LastCheckedAt    time.Time  `gorm:"index" json:"last_checked_at"`
+	LastSentAt       *time.Time `json:"last_sent_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
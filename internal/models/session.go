@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session is one refresh token of a signed-in device. Every refresh rotates
+// the token into a new row of the same FamilyID; access tokens carry the
+// family as their session id, so revoking a family logs that device out.
+type Session struct {
+	ID        uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;index" json:"user_id"`
+	FamilyID  uuid.UUID  `gorm:"type:uuid;index" json:"family_id"`
+	TokenHash string     `gorm:"uniqueIndex;not null" json:"-"` // SHA-256 of the refresh token
+	ExpiresAt time.Time  `json:"expires_at"`
+	RotatedAt *time.Time `json:"rotated_at,omitempty"` // set once exchanged for a new token
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	// logout|logout_all|reuse_detected|password_reset
+	RevokedReason string    `json:"revoked_reason,omitempty"`
+	UserAgent     string    `json:"user_agent"`
+	IP            string    `json:"ip"`
+	CreatedAt     time.Time `json:"created_at"`
+}
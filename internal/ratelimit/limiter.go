@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"context"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Limiter allows Limit events per key in each fixed Window.
+type Limiter struct {
+	Store Store
+	// Prefix namespaces the keys, e.g. "rl:auth".
+	Prefix string
+	Limit  int64
+	Window time.Duration
+}
+
+// Result is the outcome of one Allow call.
+type Result struct {
+	Allowed    bool
+	Limit      int64
+	Remaining  int64
+	RetryAfter time.Duration // until the window resets; set when not allowed
+}
+
+// Allow counts one event for key and reports whether it is within the limit.
+func (l *Limiter) Allow(ctx context.Context, key string) (Result, error) {
+	n, ttl, err := l.Store.Incr(ctx, l.Prefix+":"+key, l.Window)
+	if err != nil {
+		return Result{}, err
+	}
+	res := Result{Allowed: n <= l.Limit, Limit: l.Limit, Remaining: l.Limit - n}
+	if res.Remaining < 0 {
+		res.Remaining = 0
+	}
+	if !res.Allowed {
+		res.RetryAfter = ttl
+	}
+	return res, nil
+}
+
+// KeyFunc picks the key a request is counted under.
+type KeyFunc func(c *gin.Context) string
+
+// ByIP counts requests per client IP.
+func ByIP(c *gin.Context) string { return c.ClientIP() }
+
+// Middleware rejects requests over l's limit with 429 and sets the
+// X-RateLimit-* headers. If the store is unreachable requests are let
+// through rather than taking the endpoints down with it.
+func Middleware(l *Limiter, key KeyFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		res, err := l.Allow(c.Request.Context(), key(c))
+		if err != nil {
+			log.Printf("rate limit %s: %v", l.Prefix, err)
+			c.Next()
+			return
+		}
+		c.Header("X-RateLimit-Limit", strconv.FormatInt(res.Limit, 10))
+		c.Header("X-RateLimit-Remaining", strconv.FormatInt(res.Remaining, 10))
+		if !res.Allowed {
+			c.Header("Retry-After", RetryAfterSeconds(res.RetryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "too many requests; slow down"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RetryAfterSeconds formats d for a Retry-After header, rounding up.
+func RetryAfterSeconds(d time.Duration) string {
+	s := int64(math.Ceil(d.Seconds()))
+	if s < 1 {
+		s = 1
+	}
+	return strconv.FormatInt(s, 10)
+}
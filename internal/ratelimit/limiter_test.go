@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLimiterAllowsLimitPerWindow(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := &MemoryStore{Now: func() time.Time { return now }}
+	l := &Limiter{Store: store, Prefix: "rl:test", Limit: 3, Window: time.Minute}
+
+	for i := int64(1); i <= 3; i++ {
+		res, err := l.Allow(ctx, "1.2.3.4")
+		if err != nil || !res.Allowed || res.Remaining != 3-i {
+			t.Fatalf("hit %d = %+v, %v; want allowed with %d remaining", i, res, err, 3-i)
+		}
+	}
+	now = now.Add(20 * time.Second)
+	res, err := l.Allow(ctx, "1.2.3.4")
+	if err != nil || res.Allowed || res.Remaining != 0 || res.RetryAfter != 40*time.Second {
+		t.Fatalf("hit over the limit = %+v, %v; want denied, retry after 40s", res, err)
+	}
+	if res, _ := l.Allow(ctx, "5.6.7.8"); !res.Allowed {
+		t.Errorf("another key was denied")
+	}
+
+	now = now.Add(40 * time.Second)
+	if res, _ := l.Allow(ctx, "1.2.3.4"); !res.Allowed || res.Remaining != 2 {
+		t.Errorf("first hit of the next window = %+v; want allowed with 2 remaining", res)
+	}
+}
+
+func TestMiddlewareRejectsWith429(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	l := &Limiter{Store: NewMemoryStore(), Prefix: "rl:test", Limit: 1, Window: time.Minute}
+	r := gin.New()
+	r.GET("/", Middleware(l, func(*gin.Context) string { return "k" }), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	codes := make([]int, 2)
+	var last *httptest.ResponseRecorder
+	for i := range codes {
+		last = httptest.NewRecorder()
+		r.ServeHTTP(last, httptest.NewRequest(http.MethodGet, "/", nil))
+		codes[i] = last.Code
+	}
+	if codes[0] != http.StatusOK || codes[1] != http.StatusTooManyRequests {
+		t.Fatalf("status codes = %v; want [200 429]", codes)
+	}
+	if got := last.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("Retry-After = %q; want 60", got)
+	}
+}
+
+func TestRetryAfterSecondsRoundsUp(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		0:                       "1",
+		1500 * time.Millisecond: "2",
+		30 * time.Second:        "30",
+	} {
+		if got := RetryAfterSeconds(d); got != want {
+			t.Errorf("RetryAfterSeconds(%v) = %s; want %s", d, got, want)
+		}
+	}
+}
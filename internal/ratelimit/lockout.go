@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Lockout defaults.
+const (
+	DefaultLockoutThreshold = 5
+	DefaultLockoutBase      = 30 * time.Second
+	DefaultLockoutMax       = time.Hour
+	DefaultLockoutMemory    = 24 * time.Hour
+)
+
+// Lockout slows down guessing. Once a key reaches Threshold failures it is
+// locked for BaseDelay, doubling with every further failure up to MaxDelay.
+// Failures are forgotten Memory after the first one, or on Reset.
+type Lockout struct {
+	Store Store
+	// Prefix namespaces the keys, e.g. "lock:login:email".
+	Prefix    string
+	Threshold int64
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	Memory    time.Duration
+}
+
+func (l *Lockout) threshold() int64 {
+	if l.Threshold > 0 {
+		return l.Threshold
+	}
+	return DefaultLockoutThreshold
+}
+
+func (l *Lockout) baseDelay() time.Duration {
+	if l.BaseDelay > 0 {
+		return l.BaseDelay
+	}
+	return DefaultLockoutBase
+}
+
+func (l *Lockout) maxDelay() time.Duration {
+	if l.MaxDelay > 0 {
+		return l.MaxDelay
+	}
+	return DefaultLockoutMax
+}
+
+func (l *Lockout) memory() time.Duration {
+	if l.Memory > 0 {
+		return l.Memory
+	}
+	return DefaultLockoutMemory
+}
+
+func (l *Lockout) failKey(key string) string { return l.Prefix + ":fail:" + key }
+func (l *Lockout) lockKey(key string) string { return l.Prefix + ":lock:" + key }
+
+// Locked returns how long key stays locked, or zero.
+func (l *Lockout) Locked(ctx context.Context, key string) (time.Duration, error) {
+	n, ttl, err := l.Store.Get(ctx, l.lockKey(key))
+	if err != nil || n == 0 {
+		return 0, err
+	}
+	return ttl, nil
+}
+
+// Fail records a failed attempt and returns how long key is now locked for,
+// or zero while it is under the threshold.
+func (l *Lockout) Fail(ctx context.Context, key string) (time.Duration, error) {
+	n, _, err := l.Store.Incr(ctx, l.failKey(key), l.memory())
+	if err != nil {
+		return 0, err
+	}
+	over := n - l.threshold()
+	if over < 0 {
+		return 0, nil
+	}
+	delay := l.maxDelay()
+	if over < 32 {
+		if d := l.baseDelay() << uint(over); d > 0 && d < delay {
+			delay = d
+		}
+	}
+	if err := l.Store.Set(ctx, l.lockKey(key), n, delay); err != nil {
+		return 0, err
+	}
+	return delay, nil
+}
+
+// Reset forgets key's failures and lifts any lock, e.g. after a success.
+func (l *Lockout) Reset(ctx context.Context, key string) error {
+	return l.Store.Delete(ctx, l.failKey(key), l.lockKey(key))
+}
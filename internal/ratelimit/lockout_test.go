@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLockoutBacksOffExponentially(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := &Lockout{
+		Store:     &MemoryStore{Now: func() time.Time { return now }},
+		Prefix:    "lock:test",
+		Threshold: 3,
+		BaseDelay: 30 * time.Second,
+		MaxDelay:  2 * time.Minute,
+	}
+
+	want := []time.Duration{0, 0, 30 * time.Second, time.Minute, 2 * time.Minute, 2 * time.Minute}
+	for i, w := range want {
+		got, err := l.Fail(ctx, "a@example.com")
+		if err != nil || got != w {
+			t.Fatalf("failure %d locked for %v, %v; want %v", i+1, got, err, w)
+		}
+	}
+	if d, err := l.Locked(ctx, "a@example.com"); err != nil || d != 2*time.Minute {
+		t.Errorf("Locked = %v, %v; want 2m", d, err)
+	}
+	if d, _ := l.Locked(ctx, "b@example.com"); d != 0 {
+		t.Errorf("another key is locked for %v", d)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if d, _ := l.Locked(ctx, "a@example.com"); d != 0 {
+		t.Errorf("still locked for %v after the delay passed", d)
+	}
+}
+
+func TestLockoutReset(t *testing.T) {
+	ctx := context.Background()
+	l := &Lockout{Store: NewMemoryStore(), Prefix: "lock:test", Threshold: 1}
+	if d, _ := l.Fail(ctx, "k"); d != DefaultLockoutBase {
+		t.Fatalf("first failure over the threshold locked for %v; want %v", d, DefaultLockoutBase)
+	}
+	if err := l.Reset(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+	if d, _ := l.Locked(ctx, "k"); d != 0 {
+		t.Errorf("locked for %v after Reset", d)
+	}
+	if d, _ := l.Fail(ctx, "k"); d != DefaultLockoutBase {
+		t.Errorf("failure after Reset locked for %v; want the base delay again", d)
+	}
+}
@@ -0,0 +1,111 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often MemoryStore drops expired keys.
+const sweepInterval = time.Minute
+
+// MemoryStore is a Store local to one process. It suits a single instance
+// and tests; counters are lost on restart.
+type MemoryStore struct {
+	// Now defaults to time.Now.
+	Now func() time.Time
+
+	mu        sync.Mutex
+	entries   map[string]memoryEntry
+	lastSweep time.Time
+}
+
+type memoryEntry struct {
+	count   int64
+	expires time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]memoryEntry)}
+}
+
+func (s *MemoryStore) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+// live returns the entry under key if it hasn't expired. Callers hold mu.
+func (s *MemoryStore) live(key string, now time.Time) (memoryEntry, bool) {
+	e, ok := s.entries[key]
+	if !ok {
+		return memoryEntry{}, false
+	}
+	if !now.Before(e.expires) {
+		delete(s.entries, key)
+		return memoryEntry{}, false
+	}
+	return e, true
+}
+
+// sweep drops expired entries at most once per sweepInterval so abandoned
+// keys don't pile up. Callers hold mu.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for k, e := range s.entries {
+		if !now.Before(e.expires) {
+			delete(s.entries, k)
+		}
+	}
+}
+
+func (s *MemoryStore) Incr(_ context.Context, key string, window time.Duration) (int64, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.entries == nil {
+		s.entries = make(map[string]memoryEntry)
+	}
+	now := s.now()
+	s.sweep(now)
+	e, ok := s.live(key, now)
+	if !ok {
+		e = memoryEntry{expires: now.Add(window)}
+	}
+	e.count++
+	s.entries[key] = e
+	return e.count, e.expires.Sub(now), nil
+}
+
+func (s *MemoryStore) Get(_ context.Context, key string) (int64, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	e, ok := s.live(key, now)
+	if !ok {
+		return 0, 0, nil
+	}
+	return e.count, e.expires.Sub(now), nil
+}
+
+func (s *MemoryStore) Set(_ context.Context, key string, value int64, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.entries == nil {
+		s.entries = make(map[string]memoryEntry)
+	}
+	s.entries[key] = memoryEntry{count: value, expires: s.now().Add(ttl)}
+	return nil
+}
+
+func (s *MemoryStore) Delete(_ context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, k := range keys {
+		delete(s.entries, k)
+	}
+	return nil
+}
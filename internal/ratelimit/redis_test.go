@@ -0,0 +1,160 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *RedisStore) {
+	t.Helper()
+	m := miniredis.RunT(t)
+	s, err := NewRedisStore("redis://" + m.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Client.Close() })
+	return m, s
+}
+
+func TestRedisStoreIncr(t *testing.T) {
+	ctx := context.Background()
+	m, s := newTestRedis(t)
+
+	if n, ttl, err := s.Incr(ctx, "k", time.Minute); err != nil || n != 1 || ttl != time.Minute {
+		t.Fatalf("first Incr = %d, %v, %v; want 1, 1m", n, ttl, err)
+	}
+	m.FastForward(15 * time.Second)
+	// later hits count in the same window without extending it
+	if n, ttl, err := s.Incr(ctx, "k", time.Minute); err != nil || n != 2 || ttl != 45*time.Second {
+		t.Fatalf("second Incr = %d, %v, %v; want 2, 45s", n, ttl, err)
+	}
+	if n, ttl, err := s.Get(ctx, "k"); err != nil || n != 2 || ttl != 45*time.Second {
+		t.Fatalf("Get = %d, %v, %v; want 2, 45s", n, ttl, err)
+	}
+
+	m.FastForward(45 * time.Second)
+	if n, ttl, err := s.Incr(ctx, "k", time.Minute); err != nil || n != 1 || ttl != time.Minute {
+		t.Errorf("Incr after the window = %d, %v, %v; want a fresh 1, 1m", n, ttl, err)
+	}
+}
+
+func TestRedisStoreGetSetDelete(t *testing.T) {
+	ctx := context.Background()
+	_, s := newTestRedis(t)
+
+	if n, ttl, err := s.Get(ctx, "missing"); err != nil || n != 0 || ttl != 0 {
+		t.Fatalf("Get of a missing key = %d, %v, %v; want zeros", n, ttl, err)
+	}
+	if err := s.Set(ctx, "k", 7, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if n, ttl, err := s.Get(ctx, "k"); err != nil || n != 7 || ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("Get after Set = %d, %v, %v; want 7 expiring within 1m", n, ttl, err)
+	}
+	if err := s.Delete(ctx, "k", "missing"); err != nil {
+		t.Fatal(err)
+	}
+	if n, _, err := s.Get(ctx, "k"); err != nil || n != 0 {
+		t.Errorf("Get after Delete = %d, %v; want 0", n, err)
+	}
+}
+
+func TestRedisStoreAuth(t *testing.T) {
+	ctx := context.Background()
+	m := miniredis.RunT(t)
+	m.RequireUserAuth("limiter", "secret")
+
+	for url, ok := range map[string]bool{
+		"redis://limiter:wrong@" + m.Addr():  false,
+		"redis://limiter:secret@" + m.Addr(): true,
+	} {
+		s, err := NewRedisStore(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Ping(ctx); (err == nil) != ok {
+			t.Errorf("Ping via %s = %v; want ok %v", url, err, ok)
+		}
+		s.Client.Close()
+	}
+}
+
+func TestRedisStoreTLS(t *testing.T) {
+	cert, pool := selfSignedCert(t)
+	m := miniredis.NewMiniRedis()
+	if err := m.StartTLS(&tls.Config{Certificates: []tls.Certificate{cert}}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(m.Close)
+	m.RequireAuth("secret")
+
+	s, err := NewRedisStore("rediss://:secret@" + m.Addr() + "/0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Client.Close()
+	tlsConfig := s.Client.Options().TLSConfig
+	if tlsConfig == nil {
+		t.Fatal("rediss URL gave a plain-text client")
+	}
+	tlsConfig.RootCAs = pool
+	if err := s.Ping(context.Background()); err != nil {
+		t.Errorf("Ping over TLS = %v", err)
+	}
+}
+
+func TestNewRedisStore(t *testing.T) {
+	s, err := NewRedisStore("redis://:pw@cache:6380/2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opt := s.Client.Options(); opt.Addr != "cache:6380" || opt.Password != "pw" || opt.DB != 2 || opt.TLSConfig != nil {
+		t.Fatalf("NewRedisStore options = %+v", opt)
+	}
+	if s, err := NewRedisStore("redis://cache"); err != nil || s.Client.Options().Addr != "cache:6379" {
+		t.Errorf("default port: %+v, %v", s, err)
+	}
+	if _, err := NewRedisStore("http://cache"); err == nil {
+		t.Errorf("accepted an http URL")
+	}
+}
+
+// selfSignedCert returns a certificate for 127.0.0.1 and a pool trusting it.
+func selfSignedCert(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, pool
+}
@@ -0,0 +1,42 @@
+// Package ratelimit counts events per key in expiring windows. It backs the
+// request rate limiter middleware and the login/OTP lockouts, and keeps its
+// counters either in process memory or in Redis so limits hold across
+// replicas.
+package ratelimit
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+)
+
+// Store keeps expiring counters. Implementations must be safe for
+// concurrent use.
+type Store interface {
+	// Incr adds one to key and returns the new count and how long until the
+	// key expires. A missing or expired key starts at zero with a fresh
+	// window; later increments don't extend it.
+	Incr(ctx context.Context, key string, window time.Duration) (count int64, ttl time.Duration, err error)
+	// Get returns the count and remaining lifetime of key, or zeros when it
+	// doesn't exist.
+	Get(ctx context.Context, key string) (count int64, ttl time.Duration, err error)
+	// Set stores value under key for ttl, replacing any previous value.
+	Set(ctx context.Context, key string, value int64, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// NewStoreFromEnv returns a Redis store when REDIS_URL is set (for example
+// redis://:password@redis:6379/0) and an in-memory store otherwise.
+func NewStoreFromEnv() Store {
+	if raw := os.Getenv("REDIS_URL"); raw != "" {
+		s, err := NewRedisStore(raw)
+		if err == nil {
+			return s
+		}
+		log.Printf("invalid REDIS_URL (%v); using in-memory rate limits", err)
+		return NewMemoryStore()
+	}
+	log.Println("REDIS_URL not set; using in-memory rate limits")
+	return NewMemoryStore()
+}
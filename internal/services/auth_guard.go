@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/olamideolayemi/realestate-backend/internal/ratelimit"
+)
+
+// AuthGuard throttles guessing on one credential check (passwords, OTP
+// codes) by locking out both the targeted email and the client IP. The IP
+// limit is looser since many users can share an address.
+type AuthGuard struct {
+	Email *ratelimit.Lockout
+	IP    *ratelimit.Lockout
+}
+
+// NewAuthGuard returns a guard for one kind of check, named by scope, with
+// the default thresholds.
+func NewAuthGuard(store ratelimit.Store, scope string) *AuthGuard {
+	return &AuthGuard{
+		Email: &ratelimit.Lockout{
+			Store:     store,
+			Prefix:    "lock:" + scope + ":email",
+			Threshold: 5,
+			BaseDelay: 30 * time.Second,
+			MaxDelay:  time.Hour,
+		},
+		IP: &ratelimit.Lockout{
+			Store:     store,
+			Prefix:    "lock:" + scope + ":ip",
+			Threshold: 20,
+			BaseDelay: time.Minute,
+			MaxDelay:  time.Hour,
+		},
+	}
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Locked returns how long the caller must wait before trying again, or
+// zero. Store errors are logged and treated as unlocked so an outage
+// doesn't lock everyone out.
+func (g *AuthGuard) Locked(ctx context.Context, email, ip string) time.Duration {
+	var wait time.Duration
+	if d, err := g.Email.Locked(ctx, normalizeEmail(email)); err != nil {
+		log.Printf("auth guard %s: %v", g.Email.Prefix, err)
+	} else if d > wait {
+		wait = d
+	}
+	if d, err := g.IP.Locked(ctx, ip); err != nil {
+		log.Printf("auth guard %s: %v", g.IP.Prefix, err)
+	} else if d > wait {
+		wait = d
+	}
+	return wait
+}
+
+// Fail records a failed check against both email and ip.
+func (g *AuthGuard) Fail(ctx context.Context, email, ip string) {
+	if _, err := g.Email.Fail(ctx, normalizeEmail(email)); err != nil {
+		log.Printf("auth guard %s: %v", g.Email.Prefix, err)
+	}
+	if _, err := g.IP.Fail(ctx, ip); err != nil {
+		log.Printf("auth guard %s: %v", g.IP.Prefix, err)
+	}
+}
+
+// Succeed clears the email's failures. The IP's are kept so an attacker
+// can't reset them by signing in to an account of their own.
+func (g *AuthGuard) Succeed(ctx context.Context, email string) {
+	if err := g.Email.Reset(ctx, normalizeEmail(email)); err != nil {
+		log.Printf("auth guard %s: %v", g.Email.Prefix, err)
+	}
+}
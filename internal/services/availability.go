@@ -1 +1,224 @@
 package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+)
+
+// DateLayout is the calendar date format accepted and returned by the API.
+const DateLayout = "2006-01-02"
+
+// MaxCalendarDays caps the range a single availability request may cover.
+const MaxCalendarDays = 366
+
+// MaxStayNights caps any stay, whatever the property's own MaxNights, so a
+// quote or booking can't be made to price an unbounded number of nights.
+const MaxStayNights = 365
+
+// BlockingStatuses are the booking statuses that hold a property's nights.
+var BlockingStatuses = []string{"pending", "confirmed"}
+
+var (
+	ErrInvalidRange = errors.New("checkout must be after checkin")
+	ErrRangeTooLong = errors.New("date range too long")
+	ErrNotBookable  = errors.New("property not bookable as shortlet")
+	ErrStayTooShort = errors.New("stay is shorter than the minimum nights for this property")
+	ErrStayTooLong  = errors.New("stay is longer than the maximum nights for this property")
+	ErrNotAvailable = errors.New("property not available for selected dates")
+	ErrStayOverCap  = fmt.Errorf("stays are limited to %d nights", MaxStayNights)
+)
+
+// AvailabilityService owns the rules deciding whether a property's nights can
+// be booked. A stay occupies the nights from checkin up to (not including)
+// checkout, so a checkout day can be another guest's checkin day. Nights are
+// held by pending/confirmed bookings and by host blocks.
+type AvailabilityService struct {
+	DB *gorm.DB
+}
+
+// CalendarDay describes a single night on the availability calendar.
+type CalendarDay struct {
+	Date            string `json:"date"`
+	Status          string `json:"status"` // available|booked|blocked
+	Reason          string `json:"reason,omitempty"`
+	Available       bool   `json:"available"`
+	CheckinAllowed  bool   `json:"checkin_allowed"`
+	CheckoutAllowed bool   `json:"checkout_allowed"`
+}
+
+// ParseDate parses a YYYY-MM-DD date as midnight UTC.
+func ParseDate(s string) (time.Time, error) {
+	return time.Parse(DateLayout, s)
+}
+
+// Nights returns the number of nights between checkin and checkout.
+func Nights(checkin, checkout time.Time) int {
+	return int(checkout.Sub(checkin).Hours() / 24)
+}
+
+// ValidateStay checks the stay against the property's booking rules and
+// returns the number of nights it covers. It does not look at other bookings.
+func (s *AvailabilityService) ValidateStay(prop *models.Property, checkin, checkout time.Time) (int, error) {
+	if prop.Category != "shortlet" {
+		return 0, ErrNotBookable
+	}
+	if !checkout.After(checkin) {
+		return 0, ErrInvalidRange
+	}
+	nights := Nights(checkin, checkout)
+	if nights <= 0 {
+		return 0, ErrInvalidRange
+	}
+	if nights > MaxStayNights {
+		return nights, ErrStayOverCap
+	}
+	if prop.MinNights > 0 && nights < prop.MinNights {
+		return nights, ErrStayTooShort
+	}
+	if prop.MaxNights > 0 && nights > prop.MaxNights {
+		return nights, ErrStayTooLong
+	}
+	return nights, nil
+}
+
+// CheckAvailability validates the stay and returns ErrNotAvailable when any of
+// its nights are already held. Pass a transaction as db to make the check and
+// a subsequent insert atomic.
+func (s *AvailabilityService) CheckAvailability(db *gorm.DB, prop *models.Property, checkin, checkout time.Time) (int, error) {
+	nights, err := s.ValidateStay(prop, checkin, checkout)
+	if err != nil {
+		return nights, err
+	}
+
+	bookings, blocks, err := s.conflicts(db, prop.ID, checkin, checkout, nil)
+	if err != nil {
+		return nights, err
+	}
+	if bookings > 0 || blocks > 0 {
+		return nights, ErrNotAvailable
+	}
+	return nights, nil
+}
+
+// CheckRebooking is CheckAvailability for moving an existing booking: the
+// nights it already holds don't count against it.
+func (s *AvailabilityService) CheckRebooking(db *gorm.DB, prop *models.Property, bookingID uuid.UUID, checkin, checkout time.Time) (int, error) {
+	nights, err := s.ValidateStay(prop, checkin, checkout)
+	if err != nil {
+		return nights, err
+	}
+	bookings, blocks, err := s.conflicts(db, prop.ID, checkin, checkout, &bookingID)
+	if err != nil {
+		return nights, err
+	}
+	if bookings > 0 || blocks > 0 {
+		return nights, ErrNotAvailable
+	}
+	return nights, nil
+}
+
+// Conflicts counts the bookings and host blocks holding any night between
+// from and to.
+func (s *AvailabilityService) Conflicts(db *gorm.DB, propertyID uuid.UUID, from, to time.Time) (bookings int64, blocks int64, err error) {
+	return s.conflicts(db, propertyID, from, to, nil)
+}
+
+func (s *AvailabilityService) conflicts(db *gorm.DB, propertyID uuid.UUID, from, to time.Time, exclude *uuid.UUID) (bookings int64, blocks int64, err error) {
+	q := db.Model(&models.Booking{}).
+		Where("property_id = ? AND status IN ? AND checkin < ? AND checkout > ?",
+			propertyID, BlockingStatuses, to, from)
+	if exclude != nil {
+		q = q.Where("id <> ?", *exclude)
+	}
+	if err = q.Count(&bookings).Error; err != nil {
+		return
+	}
+	err = db.Model(&models.PropertyBlock{}).
+		Where("property_id = ? AND start_date < ? AND end_date > ?", propertyID, to, from).
+		Count(&blocks).Error
+	return
+}
+
+// AvailableBetween is a query scope over properties that keeps only listings
+// that can take a stay from checkin to checkout.
+func (s *AvailabilityService) AvailableBetween(checkin, checkout time.Time) func(*gorm.DB) *gorm.DB {
+	nights := Nights(checkin, checkout)
+	return func(q *gorm.DB) *gorm.DB {
+		return q.
+			Where("(properties.min_nights IS NULL OR properties.min_nights <= ?)", nights).
+			Where("(properties.max_nights IS NULL OR properties.max_nights = 0 OR properties.max_nights >= ?)", nights).
+			Where("NOT EXISTS (SELECT 1 FROM bookings b WHERE b.property_id = properties.id AND b.status IN ? AND b.checkin < ? AND b.checkout > ?)",
+				BlockingStatuses, checkout, checkin).
+			Where("NOT EXISTS (SELECT 1 FROM property_blocks pb WHERE pb.property_id = properties.id AND pb.start_date < ? AND pb.end_date > ?)",
+				checkout, checkin)
+	}
+}
+
+// Calendar returns one entry per night from `from` up to (not including) `to`.
+func (s *AvailabilityService) Calendar(propertyID uuid.UUID, from, to time.Time) ([]CalendarDay, error) {
+	if !to.After(from) {
+		return nil, ErrInvalidRange
+	}
+	days := Nights(from, to)
+	if days > MaxCalendarDays {
+		return nil, ErrRangeTooLong
+	}
+
+	// load the night before `from` too so checkout_allowed is right on day one
+	start := from.AddDate(0, 0, -1)
+	var bookings []models.Booking
+	if err := s.DB.
+		Where("property_id = ? AND status IN ? AND checkin < ? AND checkout > ?",
+			propertyID, BlockingStatuses, to, start).
+		Find(&bookings).Error; err != nil {
+		return nil, err
+	}
+
+	var blocks []models.PropertyBlock
+	if err := s.DB.
+		Where("property_id = ? AND start_date < ? AND end_date > ?", propertyID, to, start).
+		Find(&blocks).Error; err != nil {
+		return nil, err
+	}
+
+	held := make(map[string]bool)
+	for _, b := range bookings {
+		for d := b.Checkin; d.Before(b.Checkout); d = d.AddDate(0, 0, 1) {
+			held[d.Format(DateLayout)] = true
+		}
+	}
+	blockedFor := make(map[string]string)
+	for _, b := range blocks {
+		for d := b.StartDate; d.Before(b.EndDate); d = d.AddDate(0, 0, 1) {
+			held[d.Format(DateLayout)] = true
+			blockedFor[d.Format(DateLayout)] = b.Reason
+		}
+	}
+
+	calendar := make([]CalendarDay, 0, days)
+	for d := from; d.Before(to); d = d.AddDate(0, 0, 1) {
+		key := d.Format(DateLayout)
+		prevFree := !held[d.AddDate(0, 0, -1).Format(DateLayout)]
+		day := CalendarDay{
+			Date:            key,
+			Status:          "available",
+			Available:       !held[key],
+			CheckinAllowed:  !held[key],
+			CheckoutAllowed: prevFree,
+		}
+		if reason, ok := blockedFor[key]; ok {
+			day.Status = "blocked"
+			day.Reason = reason
+		} else if held[key] {
+			day.Status = "booked"
+		}
+		calendar = append(calendar, day)
+	}
+	return calendar, nil
+}
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+)
+
+var (
+	ErrInvalidTransition = errors.New("booking status does not allow this change")
+	ErrStayNotStarted    = errors.New("stay has not started yet")
+	ErrInvalidGuests     = errors.New("guests must be at least 1")
+)
+
+// BookingService applies staff and guest changes to existing bookings.
+type BookingService struct {
+	DB           *gorm.DB
+	Availability *AvailabilityService
+	Pricing      *PricingService
+	// Now defaults to time.Now.
+	Now func() time.Time
+}
+
+func (s *BookingService) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+// lockBooking loads a booking for update inside tx.
+func lockBooking(tx *gorm.DB, id uuid.UUID) (*models.Booking, error) {
+	var b models.Booking
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&b, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// Confirm marks a pending booking confirmed, e.g. after an offline payment.
+func (s *BookingService) Confirm(ctx context.Context, id uuid.UUID, paymentRef string) (*models.Booking, error) {
+	var out *models.Booking
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		b, err := lockBooking(tx, id)
+		if err != nil {
+			return err
+		}
+		if b.Status != "pending" {
+			return ErrInvalidTransition
+		}
+		b.Status = "confirmed"
+		if paymentRef != "" {
+			b.PaymentRef = paymentRef
+		}
+		b.UpdatedAt = s.now()
+		out = b
+		return tx.Save(b).Error
+	})
+	return out, err
+}
+
+// MarkNoShow records that the guest of a confirmed booking never arrived.
+// The booking stops holding its remaining nights.
+func (s *BookingService) MarkNoShow(ctx context.Context, id uuid.UUID) (*models.Booking, error) {
+	var out *models.Booking
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		b, err := lockBooking(tx, id)
+		if err != nil {
+			return err
+		}
+		if b.Status != "confirmed" {
+			return ErrInvalidTransition
+		}
+		if s.now().Before(b.Checkin) {
+			return ErrStayNotStarted
+		}
+		b.Status = "no_show"
+		b.UpdatedAt = s.now()
+		out = b
+		return tx.Save(b).Error
+	})
+	return out, err
+}
+
+// BookingChange lists the fields to change; nil fields keep their value.
+type BookingChange struct {
+	Checkin  *time.Time
+	Checkout *time.Time
+	Guests   *int
+	By       *uuid.UUID
+	// Staff changes may move stays that have already started.
+	Staff bool
+}
+
+// Modify changes a pending or confirmed booking's dates and/or guests,
+// re-running the availability rules against every other booking and block,
+// and records the price difference. The property row is locked, as in
+// CreateBooking, so concurrent bookings can't claim the same nights.
+func (s *BookingService) Modify(ctx context.Context, id uuid.UUID, change BookingChange) (*models.Booking, *models.BookingModification, error) {
+	var out *models.Booking
+	var mod *models.BookingModification
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		b, err := lockBooking(tx, id)
+		if err != nil {
+			return err
+		}
+		if b.Status != "pending" && b.Status != "confirmed" {
+			return ErrInvalidTransition
+		}
+
+		checkin, checkout, guests := b.Checkin, b.Checkout, b.Guests
+		if change.Checkin != nil {
+			checkin = *change.Checkin
+		}
+		if change.Checkout != nil {
+			checkout = *change.Checkout
+		}
+		if change.Guests != nil {
+			guests = *change.Guests
+		}
+		if guests < 1 {
+			return ErrInvalidGuests
+		}
+		if !change.Staff {
+			now := s.now()
+			today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+			if !b.Checkin.After(today) {
+				return ErrStayStarted
+			}
+			if checkin.Before(today) {
+				return ErrInvalidRange
+			}
+		}
+
+		var prop models.Property
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&prop, "id = ?", b.PropertyID).Error; err != nil {
+			return err
+		}
+		nights, err := s.Availability.CheckRebooking(tx, &prop, b.ID, checkin, checkout)
+		if err != nil {
+			return err
+		}
+
+		quote, err := s.Pricing.Quote(tx, &prop, checkin, checkout, guests)
+		if err != nil {
+			return err
+		}
+		if err := s.Pricing.ReplaceLineItems(tx, b.ID, quote); err != nil {
+			return err
+		}
+
+		now := s.now()
+		newTotal := quote.Total
+		diff, err := newTotal.Sub(b.TotalAmount)
+		if err != nil {
+			return err
+		}
+		mod = &models.BookingModification{
+			ID:          uuid.New(),
+			BookingID:   b.ID,
+			ChangedBy:   change.By,
+			OldCheckin:  b.Checkin,
+			OldCheckout: b.Checkout,
+			OldGuests:   b.Guests,
+			NewCheckin:  checkin,
+			NewCheckout: checkout,
+			NewGuests:   guests,
+			OldTotal:    b.TotalAmount,
+			NewTotal:    newTotal,
+			Difference:  diff,
+			Currency:    newTotal.Currency,
+			Settlement:  "none",
+			CreatedAt:   now,
+		}
+		// only paid bookings have a balance to settle
+		if b.PaymentRef != "" {
+			switch {
+			case diff.IsPositive():
+				mod.Settlement = "owed"
+			case diff.IsNegative():
+				mod.Settlement = "refundable"
+			}
+		}
+		if err := tx.Create(mod).Error; err != nil {
+			return err
+		}
+		if b.Status == "pending" && newTotal != b.TotalAmount {
+			// checkouts opened for the old total can no longer pay for it
+			if err := tx.Model(&models.Payment{}).
+				Where("booking_id = ? AND status = ?", b.ID, "initialized").
+				Updates(map[string]interface{}{"status": "stale", "updated_at": now}).Error; err != nil {
+				return err
+			}
+		}
+
+		b.Checkin = checkin
+		b.Checkout = checkout
+		b.Guests = guests
+		b.Nights = nights
+		b.TotalAmount = newTotal
+		b.Currency = newTotal.Currency
+		if b.FXRate != nil {
+			// keep the display rate the guest booked at
+			display, err := ConvertAt(newTotal, *b.FXRate, b.DisplayCurrency)
+			if err != nil {
+				return err
+			}
+			b.DisplayTotal = &display
+		}
+		b.UpdatedAt = now
+		out = b
+		return tx.Save(b).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, mod, nil
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+	"github.com/olamideolayemi/realestate-backend/internal/utils"
+)
+
+// ExpiredHoldReason is recorded on bookings cancelled for not being paid in time.
+const ExpiredHoldReason = "payment not received within hold window"
+
+const expirySweepBatch = 100
+
+// BookingExpiryService cancels pending bookings whose payment hold has lapsed
+// so abandoned checkouts stop holding the property's nights.
+type BookingExpiryService struct {
+	DB *gorm.DB
+	// HoldWindow is how long a pending booking may wait for payment.
+	HoldWindow time.Duration
+	// Interval is the time between sweeps when running in the background.
+	Interval time.Duration
+	// Now and SendMail default to time.Now and utils.SendMail.
+	Now      func() time.Time
+	SendMail func(to, subject, body string) error
+}
+
+func (s *BookingExpiryService) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+func (s *BookingExpiryService) sendMail(to, subject, body string) error {
+	if s.SendMail != nil {
+		return s.SendMail(to, subject, body)
+	}
+	return utils.SendMail(to, subject, body)
+}
+
+// Run sweeps every Interval until ctx is cancelled.
+func (s *BookingExpiryService) Run(ctx context.Context) {
+	interval := s.Interval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := s.Sweep(ctx); err != nil {
+			log.Println("booking expiry sweep failed:", err)
+		} else if n > 0 {
+			log.Printf("booking expiry: cancelled %d unpaid bookings", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep cancels every pending booking created more than HoldWindow ago and
+// emails its guest. It returns the number of bookings cancelled.
+func (s *BookingExpiryService) Sweep(ctx context.Context) (int, error) {
+	cutoff := s.now().Add(-s.HoldWindow)
+	cancelled := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return cancelled, nil
+		}
+		var batch []models.Booking
+		if err := s.DB.WithContext(ctx).
+			Where("status = ? AND created_at < ?", "pending", cutoff).
+			Order("created_at").Limit(expirySweepBatch).
+			Find(&batch).Error; err != nil {
+			return cancelled, err
+		}
+		if len(batch) == 0 {
+			return cancelled, nil
+		}
+
+		for _, b := range batch {
+			ok, err := s.expire(ctx, &b)
+			if err != nil {
+				return cancelled, err
+			}
+			if ok {
+				cancelled++
+				s.notify(&b)
+			}
+		}
+		if len(batch) < expirySweepBatch {
+			return cancelled, nil
+		}
+	}
+}
+
+// expire cancels the booking unless it stopped being pending meanwhile,
+// e.g. because its payment webhook arrived.
+func (s *BookingExpiryService) expire(ctx context.Context, b *models.Booking) (bool, error) {
+	now := s.now()
+	res := s.DB.WithContext(ctx).Model(&models.Booking{}).
+		Where("id = ? AND status = ?", b.ID, "pending").
+		Updates(map[string]interface{}{
+			"status":              "cancelled",
+			"cancellation_reason": ExpiredHoldReason,
+			"cancelled_at":        now,
+			"updated_at":          now,
+		})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (s *BookingExpiryService) notify(b *models.Booking) {
+	if b.UserID == nil {
+		return
+	}
+	var user models.User
+	if err := s.DB.First(&user, "id = ?", *b.UserID).Error; err != nil {
+		return
+	}
+	var prop models.Property
+	s.DB.Select("title").First(&prop, "id = ?", b.PropertyID)
+
+	body := fmt.Sprintf("<p>Hi %s,<br>Your booking for <b>%s</b> from %s to %s was cancelled because we did not receive payment in time. The dates have been released; you are welcome to book again.</p>",
+		user.Name, prop.Title, b.Checkin.Format(DateLayout), b.Checkout.Format(DateLayout))
+	if err := s.sendMail(user.Email, "Your booking has expired", body); err != nil {
+		fmt.Println("Mail error:", err)
+	}
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+)
+
+func TestSweepCancelsLapsedHolds(t *testing.T) {
+	db := testDB(t)
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	user := models.User{Email: "guest@example.com", PasswordHash: "x", Name: "Guest"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	prop := models.Property{Title: "Lekki flat", Status: models.PropertyPublished}
+	if err := db.Create(&prop).Error; err != nil {
+		t.Fatal(err)
+	}
+	booking := models.Booking{
+		PropertyID: prop.ID,
+		UserID:     &user.ID,
+		Checkin:    time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+		Checkout:   time.Date(2026, 4, 3, 0, 0, 0, 0, time.UTC),
+		Nights:     2,
+		Status:     "pending",
+		CreatedAt:  created,
+		UpdatedAt:  created,
+	}
+	if err := db.Create(&booking).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	clock := created
+	var mailedTo []string
+	s := &BookingExpiryService{
+		DB:         db,
+		HoldWindow: 30 * time.Minute,
+		Now:        func() time.Time { return clock },
+		SendMail: func(to, subject, body string) error {
+			mailedTo = append(mailedTo, to)
+			return nil
+		},
+	}
+
+	clock = created.Add(29 * time.Minute)
+	if n, err := s.Sweep(context.Background()); err != nil || n != 0 {
+		t.Fatalf("sweep inside the hold window = %d, %v; want 0, nil", n, err)
+	}
+
+	clock = created.Add(31 * time.Minute)
+	if n, err := s.Sweep(context.Background()); err != nil || n != 1 {
+		t.Fatalf("sweep after the hold window = %d, %v; want 1, nil", n, err)
+	}
+	var got models.Booking
+	if err := db.First(&got, "id = ?", booking.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != "cancelled" || got.CancellationReason != ExpiredHoldReason {
+		t.Errorf("booking status %q reason %q; want cancelled, %q", got.Status, got.CancellationReason, ExpiredHoldReason)
+	}
+	if got.CancelledAt == nil || !got.CancelledAt.Equal(clock) {
+		t.Errorf("cancelled_at = %v; want %v", got.CancelledAt, clock)
+	}
+	if len(mailedTo) != 1 || mailedTo[0] != user.Email {
+		t.Errorf("mailed %v; want [%s]", mailedTo, user.Email)
+	}
+
+	if n, err := s.Sweep(context.Background()); err != nil || n != 0 {
+		t.Errorf("second sweep = %d, %v; want 0, nil", n, err)
+	}
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+	"github.com/olamideolayemi/realestate-backend/internal/utils"
+)
+
+const icalProdID = "-//realestate-backend//availability//EN"
+
+var (
+	ErrInvalidFeedToken = errors.New("invalid calendar token")
+	ErrNoFeedSecret     = errors.New("calendar feeds need ICAL_FEED_SECRET or JWT_SECRET")
+)
+
+// CalendarService exchanges shortlet availability with other channels as
+// iCalendar feeds.
+type CalendarService struct {
+	DB *gorm.DB
+	// Secret signs feed URLs; falls back to ICAL_FEED_SECRET, then JWT_SECRET.
+	Secret string
+}
+
+// ImportResult summarises an .ics import.
+type ImportResult struct {
+	Imported  int                    `json:"imported"`
+	Skipped   int                    `json:"skipped"`
+	Removed   int64                  `json:"removed"`
+	Conflicts []models.Booking       `json:"conflicts"`
+	Blocks    []models.PropertyBlock `json:"blocks"`
+}
+
+// secret returns the feed signing key. There is no default: a well-known key
+// would let anyone forge feed tokens.
+func (s *CalendarService) secret() ([]byte, error) {
+	if s.Secret != "" {
+		return []byte(s.Secret), nil
+	}
+	if v := os.Getenv("ICAL_FEED_SECRET"); v != "" {
+		return []byte(v), nil
+	}
+	if v := os.Getenv("JWT_SECRET"); v != "" {
+		return []byte(v), nil
+	}
+	return nil, ErrNoFeedSecret
+}
+
+// FeedToken returns the token that authorises reading a property's feed.
+func (s *CalendarService) FeedToken(propertyID uuid.UUID) (string, error) {
+	key, err := s.secret()
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("ical:" + propertyID.String()))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// VerifyFeedToken checks a token produced by FeedToken in constant time.
+func (s *CalendarService) VerifyFeedToken(propertyID uuid.UUID, token string) error {
+	want, err := s.FeedToken(propertyID)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(want), []byte(token)) {
+		return ErrInvalidFeedToken
+	}
+	return nil
+}
+
+// Export writes the nights held on a property as an iCalendar feed. Guest
+// details are never included.
+func (s *CalendarService) Export(w io.Writer, prop *models.Property) error {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	var bookings []models.Booking
+	if err := s.DB.
+		Where("property_id = ? AND status IN ? AND checkout > ?", prop.ID, BlockingStatuses, today).
+		Order("checkin").Find(&bookings).Error; err != nil {
+		return err
+	}
+	var blocks []models.PropertyBlock
+	if err := s.DB.
+		Where("property_id = ? AND end_date > ?", prop.ID, today).
+		Order("start_date").Find(&blocks).Error; err != nil {
+		return err
+	}
+
+	events := make([]utils.ICalEvent, 0, len(bookings)+len(blocks))
+	for _, b := range bookings {
+		events = append(events, utils.ICalEvent{
+			UID:     b.ID.String() + "@realestate-backend",
+			Summary: "Reserved",
+			Start:   b.Checkin,
+			End:     b.Checkout,
+			Stamp:   b.UpdatedAt,
+		})
+	}
+	for _, b := range blocks {
+		events = append(events, utils.ICalEvent{
+			UID:     b.ID.String() + "@realestate-backend",
+			Summary: "Not available",
+			Start:   b.StartDate,
+			End:     b.EndDate,
+			Stamp:   b.UpdatedAt,
+		})
+	}
+	return utils.WriteICal(w, icalProdID, prop.Title, events)
+}
+
+// Import replaces the blocks previously imported from source with the
+// VEVENTs in r. Past and cancelled events are skipped. Bookings that overlap
+// an imported event are returned as conflicts so staff can resolve the
+// double-booking; the import itself still applies.
+func (s *CalendarService) Import(r io.Reader, propertyID uuid.UUID, source string, createdBy *uuid.UUID) (*ImportResult, error) {
+	source = strings.TrimSpace(source)
+	if source == "" || source == "manual" {
+		source = "ical"
+	}
+	events, err := utils.ParseICal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	res := &ImportResult{Conflicts: []models.Booking{}, Blocks: []models.PropertyBlock{}}
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		del := tx.Where("property_id = ? AND source = ?", propertyID, source).Delete(&models.PropertyBlock{})
+		if del.Error != nil {
+			return del.Error
+		}
+		res.Removed = del.RowsAffected
+
+		for _, e := range events {
+			if e.Cancelled || !e.End.After(today) {
+				res.Skipped++
+				continue
+			}
+			block := models.PropertyBlock{
+				ID:          uuid.New(),
+				PropertyID:  propertyID,
+				StartDate:   e.Start,
+				EndDate:     e.End,
+				Reason:      "external",
+				Note:        e.Summary,
+				Source:      source,
+				ExternalUID: e.UID,
+				CreatedBy:   createdBy,
+				CreatedAt:   time.Now(),
+				UpdatedAt:   time.Now(),
+			}
+			if err := tx.Create(&block).Error; err != nil {
+				return err
+			}
+			res.Blocks = append(res.Blocks, block)
+			res.Imported++
+
+			var overlapping []models.Booking
+			if err := tx.Where("property_id = ? AND status IN ? AND checkin < ? AND checkout > ?",
+				propertyID, BlockingStatuses, e.End, e.Start).Find(&overlapping).Error; err != nil {
+				return err
+			}
+			res.Conflicts = append(res.Conflicts, overlapping...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestFeedTokenNeedsASecret(t *testing.T) {
+	t.Setenv("ICAL_FEED_SECRET", "")
+	t.Setenv("JWT_SECRET", "")
+	id := uuid.New()
+
+	var s CalendarService
+	if _, err := s.FeedToken(id); !errors.Is(err, ErrNoFeedSecret) {
+		t.Fatalf("FeedToken with no secret: err = %v; want ErrNoFeedSecret", err)
+	}
+	if err := s.VerifyFeedToken(id, ""); !errors.Is(err, ErrNoFeedSecret) {
+		t.Errorf("VerifyFeedToken with no secret: err = %v; want ErrNoFeedSecret", err)
+	}
+
+	t.Setenv("JWT_SECRET", "jwt")
+	token, err := s.FeedToken(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.VerifyFeedToken(id, token); err != nil {
+		t.Errorf("VerifyFeedToken(own token) = %v", err)
+	}
+	if err := s.VerifyFeedToken(uuid.New(), token); !errors.Is(err, ErrInvalidFeedToken) {
+		t.Errorf("token accepted for another property: %v", err)
+	}
+	t.Setenv("ICAL_FEED_SECRET", "feed")
+	if err := s.VerifyFeedToken(id, token); !errors.Is(err, ErrInvalidFeedToken) {
+		t.Errorf("ICAL_FEED_SECRET did not take precedence: %v", err)
+	}
+}
@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+)
+
+var (
+	ErrNotCancellable = errors.New("booking cannot be cancelled")
+	ErrStayStarted    = errors.New("stay has already started")
+	ErrUnknownPolicy  = errors.New("cancellation policy must be one of flexible, moderate, strict")
+)
+
+// DefaultCancellationPolicy applies to properties without a policy set.
+const DefaultCancellationPolicy = "moderate"
+
+// refundTier refunds Percent of the paid amount when the guest cancels at
+// least MinDays before checkin.
+type refundTier struct {
+	MinDays int
+	Percent int
+}
+
+// CancellationPolicies lists refund tiers per policy, most generous first.
+var CancellationPolicies = map[string][]refundTier{
+	"flexible": {{MinDays: 1, Percent: 100}},
+	"moderate": {{MinDays: 5, Percent: 100}, {MinDays: 1, Percent: 50}},
+	"strict":   {{MinDays: 14, Percent: 100}, {MinDays: 7, Percent: 50}},
+}
+
+// ValidCancellationPolicy reports whether p names a known policy.
+func ValidCancellationPolicy(p string) bool {
+	_, ok := CancellationPolicies[p]
+	return ok
+}
+
+// RefundPercent returns the share of the paid amount refunded under policy
+// when cancelling daysBefore days ahead of checkin.
+func RefundPercent(policy string, daysBefore int) int {
+	tiers, ok := CancellationPolicies[policy]
+	if !ok {
+		tiers = CancellationPolicies[DefaultCancellationPolicy]
+	}
+	for _, t := range tiers {
+		if daysBefore >= t.MinDays {
+			return t.Percent
+		}
+	}
+	return 0
+}
+
+// CancellationService cancels bookings and refunds what the policy allows.
+type CancellationService struct {
+	DB       *gorm.DB
+	Payments *PaymentService
+	// Now defaults to time.Now.
+	Now func() time.Time
+}
+
+// CancelOptions describes who is cancelling and why.
+type CancelOptions struct {
+	By     *uuid.UUID
+	Reason string
+	// RefundPercent overrides the property's policy when set (staff only).
+	RefundPercent *int
+}
+
+func (s *CancellationService) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+// Cancel moves a pending or confirmed booking to cancelled, releasing its
+// nights, records the cancellation and refunds the paid amount per policy.
+// Unpaid bookings are cancelled without a refund.
+func (s *CancellationService) Cancel(ctx context.Context, bookingID uuid.UUID, opts CancelOptions) (*models.Booking, *models.BookingCancellation, error) {
+	var booking models.Booking
+	var record models.BookingCancellation
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&booking, "id = ?", bookingID).Error; err != nil {
+			return err
+		}
+		if booking.Status != "pending" && booking.Status != "confirmed" {
+			return ErrNotCancellable
+		}
+
+		now := s.now()
+		utc := now.UTC()
+		today := time.Date(utc.Year(), utc.Month(), utc.Day(), 0, 0, 0, 0, time.UTC)
+		daysBefore := Nights(today, booking.Checkin)
+		if daysBefore < 0 && opts.RefundPercent == nil {
+			return ErrStayStarted
+		}
+
+		var prop models.Property
+		if err := tx.Select("cancellation_policy").First(&prop, "id = ?", booking.PropertyID).Error; err != nil {
+			return err
+		}
+		policy := prop.CancellationPolicy
+		if !ValidCancellationPolicy(policy) {
+			policy = DefaultCancellationPolicy
+		}
+
+		percent := RefundPercent(policy, daysBefore)
+		if opts.RefundPercent != nil {
+			percent = *opts.RefundPercent
+		}
+		refund := models.NewMoney(0, booking.Currency)
+		if booking.PaymentRef != "" {
+			var err error
+			if refund, err = refundAmount(tx, &booking, percent, daysBefore); err != nil {
+				return err
+			}
+			if refund, err = refundableBalance(tx, booking.PaymentRef, refund); err != nil {
+				return err
+			}
+		}
+
+		record = models.BookingCancellation{
+			ID:                uuid.New(),
+			BookingID:         booking.ID,
+			CancelledBy:       opts.By,
+			Reason:            opts.Reason,
+			Policy:            policy,
+			DaysBeforeCheckin: daysBefore,
+			RefundPercent:     percent,
+			RefundAmount:      refund,
+			Currency:          refund.Currency,
+			RefundStatus:      "none",
+			CreatedAt:         now,
+			UpdatedAt:         now,
+		}
+		if refund.IsPositive() {
+			record.RefundStatus = "pending"
+		}
+		if err := tx.Create(&record).Error; err != nil {
+			return err
+		}
+
+		booking.Status = "cancelled"
+		booking.CancellationReason = opts.Reason
+		booking.CancelledAt = &now
+		booking.UpdatedAt = now
+		return tx.Save(&booking).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if record.RefundStatus == "pending" {
+		s.refund(ctx, &booking, &record)
+	}
+	return &booking, &record, nil
+}
+
+// refundAmount is what the guest gets back when percent of the stay is
+// refundable: percent of the nights (less any stay discount), the cleaning
+// fee unless the stay has started, and refundable items such as the caution
+// deposit in full. Bookings priced before line items were kept refund
+// percent of their total.
+func refundAmount(tx *gorm.DB, booking *models.Booking, percent, daysBefore int) (models.Money, error) {
+	var items []models.BookingLineItem
+	if err := tx.Where("booking_id = ?", booking.ID).Find(&items).Error; err != nil {
+		return models.Money{}, err
+	}
+	if len(items) == 0 {
+		return booking.TotalAmount.Percent(percent)
+	}
+	stay := models.NewMoney(0, booking.Currency)
+	full := models.NewMoney(0, booking.Currency)
+	var err error
+	for _, it := range items {
+		switch {
+		case it.Refundable, it.Kind == "cleaning_fee" && daysBefore >= 0:
+			full, err = full.Add(it.Amount)
+		case it.Kind == "nightly", it.Kind == "discount":
+			stay, err = stay.Add(it.Amount)
+		}
+		if err != nil {
+			return models.Money{}, err
+		}
+	}
+	refunded, err := stay.Percent(percent)
+	if err != nil {
+		return models.Money{}, err
+	}
+	return full.Add(refunded)
+}
+
+// refundableBalance caps refund at what is left of the payment: a stay
+// repriced upwards after payment has line items worth more than the guest
+// paid. Offline payment references have no payment row and are not capped.
+func refundableBalance(tx *gorm.DB, reference string, refund models.Money) (models.Money, error) {
+	var p models.Payment
+	err := tx.Where("reference = ?", reference).First(&p).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return refund, nil
+	}
+	if err != nil {
+		return models.Money{}, err
+	}
+	left, err := p.Amount.Sub(p.RefundedAmount)
+	if err != nil {
+		return models.Money{}, err
+	}
+	if refund.Currency == left.Currency && refund.Amount > left.Amount {
+		return left, nil
+	}
+	return refund, nil
+}
+
+// refund issues the recorded refund at the gateway. Failures are kept on the
+// record for staff to retry rather than undoing the cancellation.
+func (s *CancellationService) refund(ctx context.Context, booking *models.Booking, record *models.BookingCancellation) {
+	ref, err := s.Payments.Refund(ctx, booking.PaymentRef, record.RefundAmount)
+	record.UpdatedAt = s.now()
+	if err != nil {
+		log.Printf("refund for booking %s failed: %v", booking.ID, err)
+		record.RefundStatus = "failed"
+	} else {
+		record.RefundStatus = "processed"
+		record.RefundRef = ref
+	}
+	if err := s.DB.Save(record).Error; err != nil {
+		log.Printf("failed to save refund status for booking %s: %v", booking.ID, err)
+	}
+}
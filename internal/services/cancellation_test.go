@@ -0,0 +1,35 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+)
+
+func TestRefundableBalanceCapsAtWhatWasPaid(t *testing.T) {
+	db := testDB(t)
+	p := models.Payment{
+		Reference:      "bk_cap",
+		Amount:         models.NewMoney(5000000, "NGN"),
+		Currency:       "NGN",
+		Status:         "success",
+		RefundedAmount: models.NewMoney(1000000, "NGN"),
+	}
+	if err := db.Create(&p).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		ref          string
+		refund, want int64
+	}{
+		{"bk_cap", 2500000, 2500000},
+		{"bk_cap", 6500000, 4000000}, // repriced upwards after payment
+		{"offline-transfer-17", 6500000, 6500000},
+	} {
+		got, err := refundableBalance(db, tc.ref, models.NewMoney(tc.refund, "NGN"))
+		if err != nil || got != models.NewMoney(tc.want, "NGN") {
+			t.Errorf("refundableBalance(%s, %d) = %v, %v; want %d", tc.ref, tc.refund, got, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+	"github.com/olamideolayemi/realestate-backend/internal/utils"
+)
+
+// MaxWishlists caps the wishlists of one user.
+const MaxWishlists = 50
+
+var ErrTooManyWishlists = fmt.Errorf("at most %d wishlists per user", MaxWishlists)
+
+// FavoriteOrder and WishlistItemOrder are the orders of the favorites and
+// wishlist lists, most recently added first.
+var (
+	FavoriteOrder = []utils.SortColumn{
+		{Column: "user_favorites.created_at", Desc: true},
+		{Column: "user_favorites.id", Desc: true},
+	}
+	WishlistItemOrder = []utils.SortColumn{
+		{Column: "wishlist_items.created_at", Desc: true},
+		{Column: "wishlist_items.property_id", Desc: true},
+	}
+)
+
+// FavoriteService keeps users' favorite properties and wishlists.
+type FavoriteService struct {
+	DB *gorm.DB
+}
+
+// publishedProperty checks that id is a property the public can see.
+func (s *FavoriteService) publishedProperty(ctx context.Context, id uuid.UUID) error {
+	var p models.Property
+	return s.DB.WithContext(ctx).Scopes(Published).Select("id").First(&p, "id = ?", id).Error
+}
+
+// Favorite adds a published property to the user's favorites. Adding it
+// again changes nothing; created reports whether it was new.
+func (s *FavoriteService) Favorite(ctx context.Context, userID, propertyID uuid.UUID) (created bool, err error) {
+	if err := s.publishedProperty(ctx, propertyID); err != nil {
+		return false, err
+	}
+	res := s.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.UserFavorite{UserID: userID, PropertyID: propertyID})
+	return res.RowsAffected == 1, res.Error
+}
+
+// Unfavorite removes a property from the user's favorites.
+func (s *FavoriteService) Unfavorite(ctx context.Context, userID, propertyID uuid.UUID) error {
+	return s.DB.WithContext(ctx).
+		Where("user_id = ? AND property_id = ?", userID, propertyID).
+		Delete(&models.UserFavorite{}).Error
+}
+
+// FavoriteSet reports which of ids the user has favorited.
+func (s *FavoriteService) FavoriteSet(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) (map[uuid.UUID]bool, error) {
+	set := make(map[uuid.UUID]bool, len(ids))
+	if len(ids) == 0 {
+		return set, nil
+	}
+	var favs []uuid.UUID
+	if err := s.DB.WithContext(ctx).Model(&models.UserFavorite{}).
+		Where("user_id = ? AND property_id IN ?", userID, ids).
+		Pluck("property_id", &favs).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range favs {
+		set[id] = true
+	}
+	return set, nil
+}
+
+// Favorites returns a page of the user's favorites that are still
+// published, with the favorite rows for the paginator's cursor.
+func (s *FavoriteService) Favorites(ctx context.Context, userID uuid.UUID, page func(*gorm.DB) *gorm.DB) ([]models.UserFavorite, []models.Property, error) {
+	var favs []models.UserFavorite
+	err := s.DB.WithContext(ctx).Model(&models.UserFavorite{}).
+		Joins("JOIN properties ON properties.id = user_favorites.property_id").
+		Scopes(Published, page).
+		Where("user_favorites.user_id = ?", userID).
+		Find(&favs).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	ids := make([]uuid.UUID, len(favs))
+	for i := range favs {
+		ids[i] = favs[i].PropertyID
+	}
+	props, err := s.PropertiesInOrder(ctx, ids)
+	return favs, props, err
+}
+
+// PropertiesInOrder loads published properties with their images, in the
+// order of ids.
+func (s *FavoriteService) PropertiesInOrder(ctx context.Context, ids []uuid.UUID) ([]models.Property, error) {
+	if len(ids) == 0 {
+		return []models.Property{}, nil
+	}
+	var found []models.Property
+	if err := s.DB.WithContext(ctx).Scopes(Published).Preload("Images", ImagesInOrder).
+		Where("id IN ?", ids).Find(&found).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]models.Property, len(found))
+	for _, p := range found {
+		byID[p.ID] = p
+	}
+	props := make([]models.Property, 0, len(found))
+	for _, id := range ids {
+		if p, ok := byID[id]; ok {
+			props = append(props, p)
+		}
+	}
+	return props, nil
+}
+
+// CreateWishlist starts a named wishlist for the user.
+func (s *FavoriteService) CreateWishlist(ctx context.Context, userID uuid.UUID, name string) (*models.Wishlist, error) {
+	w := models.Wishlist{UserID: userID, Name: name}
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// lock the user so concurrent creates can't pass the cap together
+		var u models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&u, "id = ?", userID).Error; err != nil {
+			return err
+		}
+		var n int64
+		if err := tx.Model(&models.Wishlist{}).Where("user_id = ?", userID).Count(&n).Error; err != nil {
+			return err
+		}
+		if n >= MaxWishlists {
+			return ErrTooManyWishlists
+		}
+		return tx.Create(&w).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// Wishlist loads one of the user's wishlists.
+func (s *FavoriteService) Wishlist(ctx context.Context, userID, id uuid.UUID) (*models.Wishlist, error) {
+	var w models.Wishlist
+	if err := s.DB.WithContext(ctx).First(&w, "id = ? AND user_id = ?", id, userID).Error; err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// SharedWishlist loads a wishlist by its share token.
+func (s *FavoriteService) SharedWishlist(ctx context.Context, token string) (*models.Wishlist, error) {
+	var w models.Wishlist
+	if token == "" {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if err := s.DB.WithContext(ctx).First(&w, "share_token = ?", token).Error; err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// WishlistProperties returns a page of a wishlist's published properties,
+// with the item rows for the paginator's cursor.
+func (s *FavoriteService) WishlistProperties(ctx context.Context, wishlistID uuid.UUID, page func(*gorm.DB) *gorm.DB) ([]models.WishlistItem, []models.Property, error) {
+	var items []models.WishlistItem
+	err := s.DB.WithContext(ctx).Model(&models.WishlistItem{}).
+		Joins("JOIN properties ON properties.id = wishlist_items.property_id").
+		Scopes(Published, page).
+		Where("wishlist_items.wishlist_id = ?", wishlistID).
+		Find(&items).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	ids := make([]uuid.UUID, len(items))
+	for i := range items {
+		ids[i] = items[i].PropertyID
+	}
+	props, err := s.PropertiesInOrder(ctx, ids)
+	return items, props, err
+}
+
+// AddToWishlist puts a published property in the wishlist. Adding it again
+// changes nothing.
+func (s *FavoriteService) AddToWishlist(ctx context.Context, w *models.Wishlist, propertyID uuid.UUID) error {
+	if err := s.publishedProperty(ctx, propertyID); err != nil {
+		return err
+	}
+	return s.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.WishlistItem{WishlistID: w.ID, PropertyID: propertyID}).Error
+}
+
+// RemoveFromWishlist takes a property out of the wishlist.
+func (s *FavoriteService) RemoveFromWishlist(ctx context.Context, w *models.Wishlist, propertyID uuid.UUID) error {
+	return s.DB.WithContext(ctx).
+		Where("wishlist_id = ? AND property_id = ?", w.ID, propertyID).
+		Delete(&models.WishlistItem{}).Error
+}
+
+// DeleteWishlist removes a wishlist and its items.
+func (s *FavoriteService) DeleteWishlist(ctx context.Context, w *models.Wishlist) error {
+	return s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("wishlist_id = ?", w.ID).Delete(&models.WishlistItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(w).Error
+	})
+}
+
+// Share gives the wishlist a share token, keeping any it already has.
+func (s *FavoriteService) Share(ctx context.Context, w *models.Wishlist) error {
+	if w.ShareToken != nil {
+		return nil
+	}
+	b := make([]byte, 18)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	if err := s.DB.WithContext(ctx).Model(w).Update("share_token", token).Error; err != nil {
+		return err
+	}
+	w.ShareToken = &token
+	return nil
+}
+
+// Unshare revokes the wishlist's share link.
+func (s *FavoriteService) Unshare(ctx context.Context, w *models.Wishlist) error {
+	if err := s.DB.WithContext(ctx).Model(w).Update("share_token", nil).Error; err != nil {
+		return err
+	}
+	w.ShareToken = nil
+	return nil
+}
+
+// DeleteFavoritesOf removes a property from every favorite list and
+// wishlist, inside the transaction that deletes it.
+func DeleteFavoritesOf(tx *gorm.DB, propertyID uuid.UUID) error {
+	if err := tx.Where("property_id = ?", propertyID).Delete(&models.UserFavorite{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("property_id = ?", propertyID).Delete(&models.WishlistItem{}).Error
+}
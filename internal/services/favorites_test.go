@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+)
+
+// favoritesFixture is a user, one published and one draft listing.
+func favoritesFixture(t *testing.T, db *gorm.DB) (*FavoriteService, *models.User, *models.Property, *models.Property) {
+	t.Helper()
+	u := models.User{Email: "saver@example.com", PasswordHash: "x", Name: "Bisi", Role: models.RoleUser, IsVerified: true}
+	if err := db.Create(&u).Error; err != nil {
+		t.Fatal(err)
+	}
+	live := models.Property{Title: "Lekki studio", Status: models.PropertyPublished, Currency: "NGN"}
+	draft := models.Property{Title: "Unfinished", Status: models.PropertyDraft, Currency: "NGN"}
+	for _, p := range []*models.Property{&live, &draft} {
+		if err := db.Create(p).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &FavoriteService{DB: db}, &u, &live, &draft
+}
+
+func newestFirst(column string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB { return db.Order(column + " DESC") }
+}
+
+func TestFavorite(t *testing.T) {
+	db := testDB(t)
+	s, u, live, draft := favoritesFixture(t, db)
+	ctx := context.Background()
+
+	if created, err := s.Favorite(ctx, u.ID, live.ID); err != nil || !created {
+		t.Fatalf("Favorite = %v, %v; want created", created, err)
+	}
+	if created, err := s.Favorite(ctx, u.ID, live.ID); err != nil || created {
+		t.Errorf("second Favorite = %v, %v; want an unchanged favorite", created, err)
+	}
+	if _, err := s.Favorite(ctx, u.ID, draft.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Favorite of a draft: err = %v; want not found", err)
+	}
+
+	set, err := s.FavoriteSet(ctx, u.ID, []uuid.UUID{live.ID, draft.ID})
+	if err != nil || !set[live.ID] || set[draft.ID] {
+		t.Errorf("FavoriteSet = %v, %v", set, err)
+	}
+
+	// a favorite that is unpublished later drops out of the list
+	if err := db.Model(live).Update("status", models.PropertyArchived).Error; err != nil {
+		t.Fatal(err)
+	}
+	favs, props, err := s.Favorites(ctx, u.ID, newestFirst("user_favorites.created_at"))
+	if err != nil || len(favs) != 0 || len(props) != 0 {
+		t.Errorf("Favorites after archiving = %v, %v, %v; want none", favs, props, err)
+	}
+
+	if err := s.Unfavorite(ctx, u.ID, live.ID); err != nil {
+		t.Fatal(err)
+	}
+	if set, err := s.FavoriteSet(ctx, u.ID, []uuid.UUID{live.ID}); err != nil || set[live.ID] {
+		t.Errorf("FavoriteSet after Unfavorite = %v, %v", set, err)
+	}
+}
+
+func TestWishlistSharing(t *testing.T) {
+	db := testDB(t)
+	s, u, live, draft := favoritesFixture(t, db)
+	ctx := context.Background()
+
+	w, err := s.CreateWishlist(ctx, u.ID, "Detty December")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddToWishlist(ctx, w, live.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddToWishlist(ctx, w, live.ID); err != nil {
+		t.Errorf("adding a listing twice: %v", err)
+	}
+	if err := s.AddToWishlist(ctx, w, draft.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("adding a draft: err = %v; want not found", err)
+	}
+	if _, err := s.Wishlist(ctx, uuid.New(), w.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("someone else's wishlist: err = %v; want not found", err)
+	}
+
+	if _, err := s.SharedWishlist(ctx, ""); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("empty share token: err = %v; want not found", err)
+	}
+	if err := s.Share(ctx, w); err != nil || w.ShareToken == nil {
+		t.Fatalf("Share = %v, token %v", err, w.ShareToken)
+	}
+	token := *w.ShareToken
+	if err := s.Share(ctx, w); err != nil || *w.ShareToken != token {
+		t.Errorf("sharing again changed the link: %v, %v", w.ShareToken, err)
+	}
+	shared, err := s.SharedWishlist(ctx, token)
+	if err != nil || shared.ID != w.ID {
+		t.Fatalf("SharedWishlist = %+v, %v", shared, err)
+	}
+	items, props, err := s.WishlistProperties(ctx, shared.ID, newestFirst("wishlist_items.created_at"))
+	if err != nil || len(items) != 1 || len(props) != 1 || props[0].ID != live.ID {
+		t.Errorf("WishlistProperties = %v, %v, %v; want the one listing", items, props, err)
+	}
+
+	if err := s.Unshare(ctx, w); err != nil || w.ShareToken != nil {
+		t.Fatalf("Unshare = %v, token %v", err, w.ShareToken)
+	}
+	if _, err := s.SharedWishlist(ctx, token); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("revoked link: err = %v; want not found", err)
+	}
+	if err := s.Share(ctx, w); err != nil || *w.ShareToken == token {
+		t.Errorf("re-sharing reused the revoked link: %v", err)
+	}
+}
+
+func TestWishlistCap(t *testing.T) {
+	db := testDB(t)
+	s, u, _, _ := favoritesFixture(t, db)
+	for i := 0; i < MaxWishlists; i++ {
+		if _, err := s.CreateWishlist(context.Background(), u.ID, "list"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.CreateWishlist(context.Background(), u.ID, "one more"); !errors.Is(err, ErrTooManyWishlists) {
+		t.Errorf("wishlist past the cap: err = %v; want ErrTooManyWishlists", err)
+	}
+}
@@ -0,0 +1,344 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+)
+
+var (
+	ErrInvalidCurrency = errors.New("currency must be a 3-letter ISO 4217 code")
+	ErrInvalidFXRate   = errors.New("rate must be a positive decimal number")
+	ErrNoFXRate        = errors.New("no exchange rate for currency pair")
+	ErrInvalidCSV      = errors.New("invalid CSV")
+)
+
+// fxRateScale is the number of decimal places rates are stored with.
+const fxRateScale = 12
+
+// FXService keeps the exchange rates used to show prices in a guest's
+// preferred currency. Conversions are for display only.
+type FXService struct {
+	DB *gorm.DB
+}
+
+// NormalizeCurrency upper-cases and checks an ISO 4217 code.
+func NormalizeCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 3 || strings.Trim(code, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return "", ErrInvalidCurrency
+	}
+	return code, nil
+}
+
+// ParseFXRate parses a positive decimal rate such as "0.00065".
+func ParseFXRate(s string) (*big.Rat, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok || r.Sign() <= 0 {
+		return nil, ErrInvalidFXRate
+	}
+	return r, nil
+}
+
+// formatRate renders r with the stored precision, without trailing zeros.
+func formatRate(r *big.Rat) string {
+	s := r.FloatString(fxRateScale)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// FXQuote is a rate from one currency to another as of AsOf.
+type FXQuote struct {
+	From string    `json:"from"`
+	To   string    `json:"to"`
+	Rate string    `json:"rate"`
+	AsOf time.Time `json:"as_of"`
+
+	rat *big.Rat
+}
+
+// Convert converts m, which must be in q.From, to q.To, rounding half away
+// from zero to the target currency's minor unit.
+func (q *FXQuote) Convert(m models.Money) (models.Money, error) {
+	if !strings.EqualFold(m.Currency, q.From) {
+		return models.Money{}, fmt.Errorf("%w: %s and %s", models.ErrCurrencyMismatch, m.Currency, q.From)
+	}
+	return convertMoney(m, q.rat, q.To)
+}
+
+// ConvertAt converts m to currency at a stored rate, as used for booking
+// snapshots.
+func ConvertAt(m models.Money, rate, currency string) (models.Money, error) {
+	r, err := ParseFXRate(rate)
+	if err != nil {
+		return models.Money{}, err
+	}
+	return convertMoney(m, r, currency)
+}
+
+// convertMoney converts m at rate, rounding half away from zero to to's minor
+// unit.
+func convertMoney(m models.Money, rate *big.Rat, to string) (models.Money, error) {
+	// minor units of to = minor units of from * rate * 10^(exp(to) - exp(from))
+	v := new(big.Rat).Mul(new(big.Rat).SetInt64(m.Amount), rate)
+	shift := models.CurrencyExponent(to) - models.CurrencyExponent(m.Currency)
+	pow := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(shift))), nil))
+	if shift >= 0 {
+		v.Mul(v, pow)
+	} else {
+		v.Quo(v, pow)
+	}
+
+	num, den := v.Num(), v.Denom()
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Sign() != 0 && new(big.Int).Mul(new(big.Int).Abs(r), big.NewInt(2)).Cmp(den) >= 0 {
+		if num.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	if !q.IsInt64() {
+		return models.Money{}, models.ErrMoneyOverflow
+	}
+	return models.NewMoney(q.Int64(), to), nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Quote returns the rate from one currency to another, using the inverse of
+// the stored pair when only the reverse direction is maintained.
+func (s *FXService) Quote(db *gorm.DB, from, to string) (*FXQuote, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return &FXQuote{From: from, To: to, Rate: "1", AsOf: time.Now(), rat: big.NewRat(1, 1)}, nil
+	}
+
+	var rates []models.FXRate
+	if err := db.Where("(base_currency = ? AND quote_currency = ?) OR (base_currency = ? AND quote_currency = ?)",
+		from, to, to, from).Order("updated_at DESC").Find(&rates).Error; err != nil {
+		return nil, err
+	}
+	for _, direct := range []bool{true, false} {
+		for _, r := range rates {
+			if (r.BaseCurrency == from) != direct {
+				continue
+			}
+			rat, err := ParseFXRate(r.Rate)
+			if err != nil {
+				return nil, err
+			}
+			if !direct {
+				rat.Inv(rat)
+			}
+			return &FXQuote{From: from, To: to, Rate: formatRate(rat), AsOf: r.UpdatedAt, rat: rat}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s to %s", ErrNoFXRate, from, to)
+}
+
+// Converter converts amounts in any currency into one display currency,
+// caching the rates it looks up. It is meant for a single request.
+type Converter struct {
+	FX *FXService
+	To string
+
+	quotes map[string]*FXQuote
+}
+
+// NewConverter returns a converter into currency.
+func (s *FXService) NewConverter(currency string) *Converter {
+	return &Converter{FX: s, To: strings.ToUpper(currency), quotes: make(map[string]*FXQuote)}
+}
+
+// Quote returns the cached rate from currency into the display currency.
+func (c *Converter) Quote(currency string) (*FXQuote, error) {
+	currency = strings.ToUpper(currency)
+	if q, ok := c.quotes[currency]; ok {
+		return q, nil
+	}
+	q, err := c.FX.Quote(c.FX.DB, currency, c.To)
+	if err != nil {
+		return nil, err
+	}
+	c.quotes[currency] = q
+	return q, nil
+}
+
+// Convert converts m into the display currency.
+func (c *Converter) Convert(m models.Money) (models.Money, error) {
+	q, err := c.Quote(m.Currency)
+	if err != nil {
+		return models.Money{}, err
+	}
+	return q.Convert(m)
+}
+
+// QuoteDisplay is a quote's totals converted for display.
+type QuoteDisplay struct {
+	Currency       string       `json:"currency"`
+	Rate           string       `json:"rate"`
+	RateAsOf       time.Time    `json:"rate_as_of"`
+	Subtotal       models.Money `json:"subtotal"`
+	Discount       models.Money `json:"discount"`
+	CleaningFee    models.Money `json:"cleaning_fee"`
+	CautionDeposit models.Money `json:"caution_deposit"`
+	Total          models.Money `json:"total"`
+}
+
+// DisplayQuote converts a quote's totals into currency.
+func (s *FXService) DisplayQuote(q *Quote, currency string) (*QuoteDisplay, error) {
+	fx, err := s.Quote(s.DB, q.Currency, currency)
+	if err != nil {
+		return nil, err
+	}
+	d := &QuoteDisplay{Currency: fx.To, Rate: fx.Rate, RateAsOf: fx.AsOf}
+	for _, f := range []struct {
+		dst *models.Money
+		src models.Money
+	}{
+		{&d.Subtotal, q.Subtotal},
+		{&d.Discount, q.Discount},
+		{&d.CleaningFee, q.CleaningFee},
+		{&d.CautionDeposit, q.CautionDeposit},
+		{&d.Total, q.Total},
+	} {
+		if *f.dst, err = fx.Convert(f.src); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// SnapshotBooking records on b the rate into display currency and the
+// converted total. The booking is still charged in its own currency.
+func (s *FXService) SnapshotBooking(db *gorm.DB, b *models.Booking, currency string) error {
+	fx, err := s.Quote(db, b.TotalAmount.Currency, currency)
+	if err != nil {
+		return err
+	}
+	total, err := fx.Convert(b.TotalAmount)
+	if err != nil {
+		return err
+	}
+	asOf := fx.AsOf
+	b.DisplayCurrency = fx.To
+	b.FXRate = &fx.Rate
+	b.FXRateAt = &asOf
+	b.DisplayTotal = &total
+	return nil
+}
+
+// SetRate creates or replaces the rate for a currency pair.
+func (s *FXService) SetRate(db *gorm.DB, base, quote, rate, source string, by *uuid.UUID) (*models.FXRate, error) {
+	base, err := NormalizeCurrency(base)
+	if err != nil {
+		return nil, err
+	}
+	quote, err = NormalizeCurrency(quote)
+	if err != nil {
+		return nil, err
+	}
+	if base == quote {
+		return nil, fmt.Errorf("%w: base and quote currency are the same", ErrInvalidCurrency)
+	}
+	r, err := ParseFXRate(rate)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	rec := models.FXRate{
+		ID:            uuid.New(),
+		BaseCurrency:  base,
+		QuoteCurrency: quote,
+		Rate:          formatRate(r),
+		Source:        source,
+		UpdatedBy:     by,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	err = db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "base_currency"}, {Name: "quote_currency"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "source", "updated_by", "updated_at"}),
+	}).Create(&rec).Error
+	if err != nil {
+		return nil, err
+	}
+	// on conflict the row keeps its original id
+	if err := db.First(&rec, "base_currency = ? AND quote_currency = ?", base, quote).Error; err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+// FXImportResult summarises a CSV rate upload.
+type FXImportResult struct {
+	Updated int      `json:"updated"`
+	Errors  []string `json:"errors,omitempty"`
+}
+
+// ImportCSV upserts rates from CSV rows of base,quote,rate. A header row is
+// skipped. Bad rows are reported and nothing is saved if any row is bad.
+func (s *FXService) ImportCSV(r io.Reader, by *uuid.UUID) (*FXImportResult, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCSV, err)
+	}
+
+	res := &FXImportResult{}
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		for i, row := range rows {
+			if i == 0 && len(row) > 0 && strings.EqualFold(strings.TrimSpace(row[0]), "base") {
+				continue
+			}
+			if len(row) == 1 && strings.TrimSpace(row[0]) == "" {
+				continue
+			}
+			if len(row) != 3 {
+				res.Errors = append(res.Errors, fmt.Sprintf("line %d: want base,quote,rate", i+1))
+				continue
+			}
+			if _, err := s.SetRate(tx, row[0], row[1], row[2], "csv", by); err != nil {
+				if errors.Is(err, ErrInvalidCurrency) || errors.Is(err, ErrInvalidFXRate) {
+					res.Errors = append(res.Errors, fmt.Sprintf("line %d: %v", i+1, err))
+					continue
+				}
+				return err
+			}
+			res.Updated++
+		}
+		if len(res.Errors) > 0 {
+			return errFXImportRejected
+		}
+		return nil
+	})
+	if errors.Is(err, errFXImportRejected) {
+		res.Updated = 0
+		return res, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+var errFXImportRejected = errors.New("fx import rejected")
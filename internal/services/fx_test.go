@@ -0,0 +1,65 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+)
+
+func TestConvertAt(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		amount   models.Money
+		rate, to string
+		want     int64
+	}{
+		{"NGN to USD", models.NewMoney(1500000, "NGN"), "0.00065", "USD", 975},
+		{"half a cent rounds up", models.NewMoney(3, "NGN"), "0.5", "USD", 2},
+		{"half a cent rounds away from zero", models.NewMoney(-3, "NGN"), "0.5", "USD", -2},
+		{"under half rounds down", models.NewMoney(1, "NGN"), "0.4999", "USD", 0},
+		{"refunds round like charges", models.NewMoney(-1, "NGN"), "0.4999", "USD", 0},
+		{"cents to yen", models.NewMoney(100, "USD"), "150", "JPY", 150},
+		{"a cent is a yen and a half, rounded up", models.NewMoney(1, "USD"), "150", "JPY", 2},
+		{"yen to cents", models.NewMoney(1500, "JPY"), "0.0066667", "USD", 1000},
+		{"cents to fils", models.NewMoney(1000, "USD"), "0.307", "KWD", 3070},
+		{"fils to kobo", models.NewMoney(1, "KWD"), "5000", "NGN", 500},
+		{"yen to fils", models.NewMoney(333, "JPY"), "0.002", "KWD", 666},
+		{"fractional rate", models.NewMoney(100000, "NGN"), "1/1550", "USD", 65}, // 64.516...
+	} {
+		got, err := ConvertAt(tc.amount, tc.rate, tc.to)
+		if err != nil || got != models.NewMoney(tc.want, tc.to) {
+			t.Errorf("%s: ConvertAt(%v, %s, %s) = %v, %v; want %d", tc.name, tc.amount, tc.rate, tc.to, got, err, tc.want)
+		}
+	}
+}
+
+func TestConvertAtRejects(t *testing.T) {
+	for _, rate := range []string{"", "0", "-1", "abc", "1/0"} {
+		if _, err := ConvertAt(models.NewMoney(100, "NGN"), rate, "USD"); !errors.Is(err, ErrInvalidFXRate) {
+			t.Errorf("rate %q: err = %v; want ErrInvalidFXRate", rate, err)
+		}
+	}
+	if _, err := ConvertAt(models.NewMoney(math.MaxInt64, "USD"), "2", "NGN"); !errors.Is(err, models.ErrMoneyOverflow) {
+		t.Errorf("overflowing conversion: err = %v; want ErrMoneyOverflow", err)
+	}
+	// the same amount also overflows by gaining minor-unit digits
+	if _, err := ConvertAt(models.NewMoney(math.MaxInt64, "JPY"), "1", "KWD"); !errors.Is(err, models.ErrMoneyOverflow) {
+		t.Errorf("overflowing conversion: err = %v; want ErrMoneyOverflow", err)
+	}
+}
+
+func TestFXQuoteConvertChecksCurrency(t *testing.T) {
+	rate, err := ParseFXRate("0.00065")
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := &FXQuote{From: "NGN", To: "USD", Rate: "0.00065", rat: rate}
+	if got, err := q.Convert(models.NewMoney(1500000, "NGN")); err != nil || got != models.NewMoney(975, "USD") {
+		t.Errorf("Convert = %v, %v; want 9.75 USD", got, err)
+	}
+	if _, err := q.Convert(models.NewMoney(1500000, "GHS")); !errors.Is(err, models.ErrCurrencyMismatch) {
+		t.Errorf("Convert(GHS): err = %v; want ErrCurrencyMismatch", err)
+	}
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"errors"
+	"math"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// EarthRadiusKm is the mean radius used for great-circle distances.
+	EarthRadiusKm = 6371.0088
+	// MaxRadiusKm bounds the radius_km filter.
+	MaxRadiusKm = 500
+	// kmPerDegree is the length of a degree of latitude.
+	kmPerDegree = 111.195
+)
+
+var ErrInvalidCoordinates = errors.New("latitude must be between -90 and 90 and longitude between -180 and 180")
+
+// GeoPoint is a WGS84 position in degrees.
+type GeoPoint struct {
+	Lat, Lng float64
+}
+
+// Valid reports whether p is on the globe.
+func (p GeoPoint) Valid() bool {
+	return p.Lat >= -90 && p.Lat <= 90 && p.Lng >= -180 && p.Lng <= 180
+}
+
+// DistanceKm is the haversine distance between two points.
+func DistanceKm(a, b GeoPoint) float64 {
+	dLat := (b.Lat - a.Lat) * math.Pi / 180
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+	h := math.Pow(math.Sin(dLat/2), 2) +
+		math.Cos(a.Lat*math.Pi/180)*math.Cos(b.Lat*math.Pi/180)*math.Pow(math.Sin(dLng/2), 2)
+	return 2 * EarthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// distanceSQL is DistanceKm over properties' coordinates, for a point bound
+// as (lat, lat, lng). It needs no PostGIS or earthdistance extension.
+const distanceSQL = "(2 * 6371.0088 * asin(least(1, sqrt(" +
+	"power(sin(radians(properties.latitude - ?) / 2), 2) + " +
+	"cos(radians(?)) * cos(radians(properties.latitude)) * " +
+	"power(sin(radians(properties.longitude - ?) / 2), 2)))))"
+
+// BoundingBox is a map viewport. West may be greater than East when the box
+// crosses the antimeridian.
+type BoundingBox struct {
+	West, South, East, North float64
+}
+
+// Valid reports whether the box's edges are on the globe and in order.
+func (b BoundingBox) Valid() bool {
+	return GeoPoint{b.South, b.West}.Valid() && GeoPoint{b.North, b.East}.Valid() && b.South <= b.North
+}
+
+// Where limits a property query to the box, using idx_properties_location.
+func (b BoundingBox) Where(db *gorm.DB) *gorm.DB {
+	db = db.Where("properties.latitude BETWEEN ? AND ?", b.South, b.North)
+	if b.West <= b.East {
+		return db.Where("properties.longitude BETWEEN ? AND ?", b.West, b.East)
+	}
+	return db.Where("(properties.longitude >= ? OR properties.longitude <= ?)", b.West, b.East)
+}
+
+// boxAround is a box enclosing the circle of radius km around p, used to
+// narrow a radius search with the index before computing distances.
+func boxAround(p GeoPoint, km float64) BoundingBox {
+	dLat := km / kmPerDegree
+	b := BoundingBox{South: math.Max(-90, p.Lat-dLat), North: math.Min(90, p.Lat+dLat), West: -180, East: 180}
+	if b.South == -90 || b.North == 90 {
+		return b // the circle covers a pole: every longitude
+	}
+	dLng := dLat / math.Cos(math.Max(math.Abs(b.South), math.Abs(b.North))*math.Pi/180)
+	if dLng >= 180 {
+		return b
+	}
+	b.West, b.East = p.Lng-dLng, p.Lng+dLng
+	if b.West < -180 {
+		b.West += 360
+	}
+	if b.East > 180 {
+		b.East -= 360
+	}
+	return b
+}
+
+// WithinKm limits a property query to listings within km of p.
+func WithinKm(p GeoPoint, km float64) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return boxAround(p, km).Where(db).Where(distanceSQL+" <= ?", p.Lat, p.Lat, p.Lng, km)
+	}
+}
+
+// NearestFirst orders a property query by distance from p. Listings
+// without coordinates come last. It replaces any other ordering.
+func NearestFirst(p GeoPoint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:                distanceSQL + " ASC NULLS LAST, properties.id",
+			Vars:               []interface{}{p.Lat, p.Lat, p.Lng},
+			WithoutParentheses: true,
+		}})
+	}
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+)
+
+func TestDistanceKm(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		a, b GeoPoint
+		want float64
+	}{
+		{"same point", GeoPoint{6.45, 3.4}, GeoPoint{6.45, 3.4}, 0},
+		{"one degree of latitude", GeoPoint{0, 0}, GeoPoint{1, 0}, kmPerDegree},
+		{"across the antimeridian", GeoPoint{0, 179.5}, GeoPoint{0, -179.5}, kmPerDegree},
+		{"antipodes", GeoPoint{0, 0}, GeoPoint{0, 180}, math.Pi * EarthRadiusKm},
+		{"pole to pole", GeoPoint{90, 0}, GeoPoint{-90, 0}, math.Pi * EarthRadiusKm},
+		{"Lagos to Abuja", GeoPoint{6.5244, 3.3792}, GeoPoint{9.0765, 7.3986}, 525.4},
+	} {
+		if got := DistanceKm(tc.a, tc.b); math.Abs(got-tc.want) > 0.5 {
+			t.Errorf("%s: DistanceKm = %.2f; want %.2f", tc.name, got, tc.want)
+		}
+		if got, back := DistanceKm(tc.a, tc.b), DistanceKm(tc.b, tc.a); math.Abs(got-back) > 1e-9 {
+			t.Errorf("%s: not symmetric: %v and %v", tc.name, got, back)
+		}
+	}
+}
+
+// destination is the point km from p on the given bearing in degrees.
+func destination(p GeoPoint, km, bearing float64) GeoPoint {
+	d := km / EarthRadiusKm
+	lat1, lng1, brg := p.Lat*math.Pi/180, p.Lng*math.Pi/180, bearing*math.Pi/180
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(d) + math.Cos(lat1)*math.Sin(d)*math.Cos(brg))
+	lng2 := lng1 + math.Atan2(math.Sin(brg)*math.Sin(d)*math.Cos(lat1), math.Cos(d)-math.Sin(lat1)*math.Sin(lat2))
+	lng := math.Mod(lng2*180/math.Pi+540, 360) - 180
+	return GeoPoint{lat2 * 180 / math.Pi, lng}
+}
+
+// contains mirrors BoundingBox.Where.
+func (b BoundingBox) contains(p GeoPoint) bool {
+	if p.Lat < b.South || p.Lat > b.North {
+		return false
+	}
+	if b.West <= b.East {
+		return p.Lng >= b.West && p.Lng <= b.East
+	}
+	return p.Lng >= b.West || p.Lng <= b.East
+}
+
+func TestBoxAroundEnclosesTheCircle(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		center GeoPoint
+		km     float64
+	}{
+		{"Lagos", GeoPoint{6.45, 3.4}, 25},
+		{"east of the antimeridian", GeoPoint{-17.7, 179.9}, 50},
+		{"west of the antimeridian", GeoPoint{-17.7, -179.9}, 50},
+		{"high latitude", GeoPoint{78.2, 15.6}, MaxRadiusKm},
+		{"near the north pole", GeoPoint{89.9, 45}, 50},
+		{"near the south pole", GeoPoint{-89.5, -120}, 100},
+	} {
+		b := boxAround(tc.center, tc.km)
+		if !b.Valid() {
+			t.Errorf("%s: invalid box %+v", tc.name, b)
+			continue
+		}
+		for bearing := 0.0; bearing < 360; bearing += 7.5 {
+			if p := destination(tc.center, tc.km*0.999, bearing); !b.contains(p) {
+				t.Errorf("%s: box %+v misses %+v at bearing %v", tc.name, b, p, bearing)
+			}
+		}
+	}
+}
+
+func TestBoxAroundEdges(t *testing.T) {
+	b := boxAround(GeoPoint{0, 179.9}, 50)
+	if b.West <= b.East || b.West < 179 || b.East > -179 {
+		t.Errorf("box across the antimeridian = %+v; want West > East, wrapping", b)
+	}
+	b = boxAround(GeoPoint{0, -179.9}, 50)
+	if b.West <= b.East || b.West < 179 || b.East > -179 {
+		t.Errorf("box across the antimeridian = %+v; want West > East, wrapping", b)
+	}
+	for _, p := range []GeoPoint{{89.9, 10}, {-89.9, 10}} {
+		b := boxAround(p, 50)
+		if b.West != -180 || b.East != 180 || (b.North != 90 && b.South != -90) {
+			t.Errorf("box around %+v = %+v; want every longitude up to the pole", p, b)
+		}
+	}
+	// near, but not over, a pole the longitude span can exceed the globe
+	if b := boxAround(GeoPoint{80, 0}, 1000); b.West != -180 || b.East != 180 {
+		t.Errorf("wide box at high latitude = %+v; want every longitude", b)
+	}
+}
+
+func TestBoundingBoxWhere(t *testing.T) {
+	db, err := gorm.Open(postgres.Open("host=localhost"), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name     string
+		box      BoundingBox
+		wantSQL  string
+		wantVars []interface{}
+	}{
+		{
+			"ordinary box",
+			BoundingBox{West: 3.0, South: 6.0, East: 4.0, North: 7.0},
+			`SELECT * FROM "properties" WHERE (properties.latitude BETWEEN $1 AND $2) AND (properties.longitude BETWEEN $3 AND $4)`,
+			[]interface{}{6.0, 7.0, 3.0, 4.0},
+		},
+		{
+			"box across the antimeridian",
+			BoundingBox{West: 170, South: -20, East: -170, North: -10},
+			`SELECT * FROM "properties" WHERE (properties.latitude BETWEEN $1 AND $2) AND ((properties.longitude >= $3 OR properties.longitude <= $4))`,
+			[]interface{}{-20.0, -10.0, 170.0, -170.0},
+		},
+	} {
+		var props []models.Property
+		stmt := db.Model(&models.Property{}).Scopes(tc.box.Where).Find(&props).Statement
+		if got := stmt.SQL.String(); got != tc.wantSQL {
+			t.Errorf("%s: SQL\n%s\nwant\n%s", tc.name, got, tc.wantSQL)
+		}
+		if !reflect.DeepEqual(stmt.Vars, tc.wantVars) {
+			t.Errorf("%s: vars %v; want %v", tc.name, stmt.Vars, tc.wantVars)
+		}
+	}
+}
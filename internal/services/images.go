@@ -0,0 +1,297 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/olamideolayemi/realestate-backend/internal/imaging"
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+)
+
+const (
+	DefaultMaxImageSize      = 10 << 20 // 10 MiB
+	DefaultMaxPropertyImages = 30
+)
+
+var (
+	ErrUnsupportedImage = errors.New("unsupported image type; upload JPEG, PNG or GIF")
+	ErrImageTooLarge    = errors.New("image is too large")
+	ErrTooManyImages    = errors.New("property has too many images")
+	ErrImageOrder       = errors.New("image_ids must list each of the property's images exactly once")
+)
+
+// imageTypes are the content types we accept, as sniffed from the bytes.
+var imageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// imageVariants are the resized copies stored for every upload, largest
+// first, each bounded by its longest side in pixels. The original file is
+// never stored, so its metadata (EXIF GPS position and the like) is dropped.
+var imageVariants = []struct {
+	name    string
+	maxSide int
+}{
+	{models.ImageFull, 2048},
+	{models.ImageCard, 800},
+	{models.ImageThumbnail, 320},
+}
+
+// ImagesInOrder sorts property images into gallery order.
+func ImagesInOrder(db *gorm.DB) *gorm.DB {
+	return db.Order("property_images.position ASC, property_images.created_at ASC")
+}
+
+// SniffImage returns the content type of an accepted image format, judged
+// from the bytes rather than the client's Content-Type or file name.
+func SniffImage(data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+	if !imageTypes[contentType] {
+		return "", ErrUnsupportedImage
+	}
+	return contentType, nil
+}
+
+// ImageService stores property photos in a BlobStore and keeps their order
+// and cover image.
+type ImageService struct {
+	DB    *gorm.DB
+	Blobs BlobStore
+	// MaxSize and MaxPerProperty default to the Default* values.
+	MaxSize        int64
+	MaxPerProperty int
+}
+
+func (s *ImageService) maxSize() int64 {
+	if s.MaxSize > 0 {
+		return s.MaxSize
+	}
+	return DefaultMaxImageSize
+}
+
+func (s *ImageService) maxPerProperty() int {
+	if s.MaxPerProperty > 0 {
+		return s.MaxPerProperty
+	}
+	return DefaultMaxPropertyImages
+}
+
+// MaxSizeBytes is the largest upload accepted per image.
+func (s *ImageService) MaxSizeBytes() int64 { return s.maxSize() }
+
+// Upload checks that data really is an image, stores its resized variants
+// and appends it to the property's gallery. The first image becomes the
+// cover.
+func (s *ImageService) Upload(ctx context.Context, propertyID uuid.UUID, data []byte, altText string) (*models.PropertyImage, error) {
+	if int64(len(data)) > s.maxSize() {
+		return nil, ErrImageTooLarge
+	}
+	if _, err := SniffImage(data); err != nil {
+		return nil, err
+	}
+	img := models.PropertyImage{
+		ID:         uuid.New(),
+		PropertyID: propertyID,
+		AltText:    altText,
+		CreatedAt:  time.Now(),
+	}
+	if err := s.storeVariants(ctx, &img, data); err != nil {
+		return nil, err
+	}
+	img.SetVariants()
+
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// lock the property so concurrent uploads get distinct positions
+		var p models.Property
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&p, "id = ?", propertyID).Error; err != nil {
+			return err
+		}
+		var count int64
+		if err := tx.Model(&models.PropertyImage{}).Where("property_id = ?", propertyID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= int64(s.maxPerProperty()) {
+			return ErrTooManyImages
+		}
+		var last struct{ Max *int }
+		if err := tx.Model(&models.PropertyImage{}).Select("MAX(position) AS max").
+			Where("property_id = ?", propertyID).Scan(&last).Error; err != nil {
+			return err
+		}
+		if last.Max != nil {
+			img.Position = *last.Max + 1
+		}
+		img.IsCover = count == 0
+		return tx.Create(&img).Error
+	})
+	if err != nil {
+		s.DeleteBlobs(ctx, img.BlobKeys()...)
+		return nil, err
+	}
+	return &img, nil
+}
+
+// storeVariants decodes data, writes each variant to the blob store and
+// records their keys and URLs on img.
+func (s *ImageService) storeVariants(ctx context.Context, img *models.PropertyImage, data []byte) error {
+	src, format, err := imaging.Decode(data)
+	switch {
+	case errors.Is(err, imaging.ErrUnsupported):
+		return ErrUnsupportedImage
+	case errors.Is(err, imaging.ErrTooManyPixels):
+		return ErrImageTooLarge
+	case err != nil:
+		return ErrUnsupportedImage
+	}
+
+	prefix := "properties/" + img.PropertyID.String() + "/" + img.ID.String() + "/"
+	cur := src
+	for _, v := range imageVariants {
+		// each variant is scaled from the previous, larger one
+		cur = imaging.Fit(cur, v.maxSide)
+		out, err := imaging.Encode(cur, format)
+		if err != nil {
+			s.DeleteBlobs(ctx, img.BlobKeys()...)
+			return err
+		}
+		key := prefix + v.name + format.Ext()
+		if err := s.Blobs.Put(ctx, key, out, format.ContentType()); err != nil {
+			s.DeleteBlobs(ctx, img.BlobKeys()...)
+			return err
+		}
+		switch v.name {
+		case models.ImageFull:
+			img.StorageKey, img.URL = key, s.Blobs.URL(key)
+			img.ContentType = format.ContentType()
+			img.Size = int64(len(out))
+			img.Width, img.Height = cur.Bounds().Dx(), cur.Bounds().Dy()
+		case models.ImageCard:
+			img.CardKey, img.CardURL = key, s.Blobs.URL(key)
+		case models.ImageThumbnail:
+			img.ThumbnailKey, img.ThumbnailURL = key, s.Blobs.URL(key)
+		}
+	}
+	return nil
+}
+
+// Reorder sets the gallery order to ids, which must be exactly the
+// property's images.
+func (s *ImageService) Reorder(ctx context.Context, propertyID uuid.UUID, ids []uuid.UUID) ([]models.PropertyImage, error) {
+	var imgs []models.PropertyImage
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("property_id = ?", propertyID).Find(&imgs).Error; err != nil {
+			return err
+		}
+		if len(ids) != len(imgs) {
+			return ErrImageOrder
+		}
+		known := make(map[uuid.UUID]bool, len(imgs))
+		for _, img := range imgs {
+			known[img.ID] = true
+		}
+		for pos, id := range ids {
+			if !known[id] {
+				return ErrImageOrder
+			}
+			delete(known, id) // a repeated id fails the lookup above
+			if err := tx.Model(&models.PropertyImage{}).Where("id = ?", id).Update("position", pos).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Scopes(ImagesInOrder).Where("property_id = ?", propertyID).Find(&imgs).Error
+	})
+	return imgs, err
+}
+
+// SetCover makes imageID the property's cover image.
+func (s *ImageService) SetCover(ctx context.Context, propertyID, imageID uuid.UUID) (*models.PropertyImage, error) {
+	var img models.PropertyImage
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&img, "id = ? AND property_id = ?", imageID, propertyID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.PropertyImage{}).
+			Where("property_id = ? AND id <> ? AND is_cover", propertyID, imageID).
+			Update("is_cover", false).Error; err != nil {
+			return err
+		}
+		return tx.Model(&img).Update("is_cover", true).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &img, nil
+}
+
+// Delete removes one image and its blob. If it was the cover, the next
+// image in order takes over.
+func (s *ImageService) Delete(ctx context.Context, propertyID, imageID uuid.UUID) error {
+	var img models.PropertyImage
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&img, "id = ? AND property_id = ?", imageID, propertyID).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&img).Error; err != nil {
+			return err
+		}
+		if !img.IsCover {
+			return nil
+		}
+		var next models.PropertyImage
+		err := tx.Scopes(ImagesInOrder).Where("property_id = ?", propertyID).First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Model(&next).Update("is_cover", true).Error
+	})
+	if err != nil {
+		return err
+	}
+	s.DeleteBlobs(ctx, img.BlobKeys()...)
+	return nil
+}
+
+// DeleteAll removes every image row of a property inside tx and returns the
+// blob keys to pass to DeleteBlobs once tx has committed.
+func (s *ImageService) DeleteAll(tx *gorm.DB, propertyID uuid.UUID) ([]string, error) {
+	var imgs []models.PropertyImage
+	if err := tx.Where("property_id = ?", propertyID).Find(&imgs).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("property_id = ?", propertyID).Delete(&models.PropertyImage{}).Error; err != nil {
+		return nil, err
+	}
+	var keys []string
+	for i := range imgs {
+		keys = append(keys, imgs[i].BlobKeys()...)
+	}
+	return keys, nil
+}
+
+// DeleteBlobs removes stored files. Failures are logged rather than
+// returned: the rows are already gone and a stray file is harmless.
+func (s *ImageService) DeleteBlobs(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if err := s.Blobs.Delete(ctx, key); err != nil {
+			log.Printf("delete blob %s: %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+	"github.com/olamideolayemi/realestate-backend/internal/utils"
+)
+
+var (
+	ErrInvalidListingStatus = errors.New("listing cannot move to that status")
+	ErrListingHasBookings   = errors.New("property has bookings; archive it instead")
+	ErrNotInReview          = errors.New("listing is not awaiting review")
+)
+
+// listingTransitions lists the statuses each listing status may move to.
+var listingTransitions = map[string][]string{
+	models.PropertyDraft:         {models.PropertyPendingReview, models.PropertyArchived},
+	models.PropertyPendingReview: {models.PropertyDraft, models.PropertyPublished, models.PropertyArchived},
+	models.PropertyPublished:     {models.PropertyPendingReview, models.PropertyArchived},
+	models.PropertyArchived:      {models.PropertyDraft, models.PropertyPendingReview},
+}
+
+// CanTransition reports whether a listing may move from one status to another.
+func CanTransition(from, to string) bool {
+	for _, s := range listingTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Published limits a property query to listings the public may see.
+func Published(db *gorm.DB) *gorm.DB {
+	return db.Where("properties.status = ?", models.PropertyPublished)
+}
+
+// PublishedOrOwnedBy limits a property query to published listings and
+// any of owner's own.
+func PublishedOrOwnedBy(owner uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("(properties.status = ? OR properties.owner_id = ?)", models.PropertyPublished, owner)
+	}
+}
+
+// OwnedBy limits a property query to one owner's listings.
+func OwnedBy(owner uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("properties.owner_id = ?", owner)
+	}
+}
+
+// ListingService moves listings through draft, review, publication and
+// archiving.
+type ListingService struct {
+	DB     *gorm.DB
+	Images *ImageService
+	// Now defaults to time.Now.
+	Now func() time.Time
+}
+
+func (s *ListingService) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+// Transition moves owner's property id to status to. Other people's
+// listings are reported as not found.
+func (s *ListingService) Transition(ctx context.Context, id, owner uuid.UUID, to string) (*models.Property, error) {
+	var p models.Property
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Scopes(OwnedBy(owner)).
+			First(&p, "id = ?", id).Error; err != nil {
+			return err
+		}
+		return s.transition(tx, &p, to, owner, "")
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Review approves (publishes) or rejects (returns to draft with note) a
+// listing in the review queue.
+func (s *ListingService) Review(ctx context.Context, id uuid.UUID, approve bool, reviewer uuid.UUID, note string) (*models.Property, error) {
+	var p models.Property
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&p, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if p.Status != models.PropertyPendingReview {
+			return ErrNotInReview
+		}
+		to := models.PropertyDraft
+		if approve {
+			to = models.PropertyPublished
+		}
+		return s.transition(tx, &p, to, reviewer, note)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (s *ListingService) transition(tx *gorm.DB, p *models.Property, to string, actor uuid.UUID, note string) error {
+	if !CanTransition(p.Status, to) {
+		return ErrInvalidListingStatus
+	}
+	now := s.now()
+	updates := map[string]interface{}{"status": to, "updated_at": now}
+	switch to {
+	case models.PropertyPendingReview:
+		updates["submitted_at"] = now
+		updates["review_note"] = ""
+	case models.PropertyPublished:
+		updates["published_at"] = now
+		updates["reviewed_by"] = &actor
+		updates["review_note"] = ""
+	case models.PropertyDraft:
+		if p.Status == models.PropertyPendingReview && note != "" {
+			updates["reviewed_by"] = &actor
+			updates["review_note"] = note
+		}
+	}
+	return tx.Model(p).Updates(updates).Error
+}
+
+// Resubmit sends an edited published listing back to the review queue, so
+// changes made by its owner are checked before the public sees them.
+func (s *ListingService) Resubmit(p *models.Property) {
+	if p.Status != models.PropertyPublished {
+		return
+	}
+	now := s.now()
+	p.Status = models.PropertyPendingReview
+	p.SubmittedAt = &now
+	p.ReviewNote = ""
+}
+
+// ResubmitListing is Resubmit for edits saved elsewhere, such as an owner's
+// photo changes: a published listing goes back to the review queue.
+func (s *ListingService) ResubmitListing(ctx context.Context, id uuid.UUID) error {
+	return s.DB.WithContext(ctx).Model(&models.Property{}).
+		Where("id = ? AND status = ?", id, models.PropertyPublished).
+		Updates(map[string]interface{}{
+			"status":       models.PropertyPendingReview,
+			"submitted_at": s.now(),
+			"review_note":  "",
+			"updated_at":   s.now(),
+		}).Error
+}
+
+// Delete removes an owner's listing as DeleteAny does. Other people's
+// listings are reported as not found.
+func (s *ListingService) Delete(ctx context.Context, id, owner uuid.UUID) error {
+	return s.delete(ctx, id, OwnedBy(owner))
+}
+
+// DeleteAny removes a listing with its images, rates, blocks and
+// favourites. Listings anyone has booked, even in the past, are kept so
+// their bookings, line items and payments still point somewhere;
+// ErrListingHasBookings asks for them to be archived instead.
+func (s *ListingService) DeleteAny(ctx context.Context, id uuid.UUID) error {
+	return s.delete(ctx, id)
+}
+
+func (s *ListingService) delete(ctx context.Context, id uuid.UUID, scopes ...func(*gorm.DB) *gorm.DB) error {
+	var blobs []string
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var p models.Property
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Scopes(scopes...).
+			First(&p, "id = ?", id).Error; err != nil {
+			return err
+		}
+		var bookings int64
+		if err := tx.Model(&models.Booking{}).Where("property_id = ?", p.ID).Count(&bookings).Error; err != nil {
+			return err
+		}
+		if bookings > 0 {
+			return ErrListingHasBookings
+		}
+		var err error
+		if blobs, err = s.Images.DeleteAll(tx, p.ID); err != nil {
+			return err
+		}
+		if err := DeleteFavoritesOf(tx, p.ID); err != nil {
+			return err
+		}
+		for _, child := range []interface{}{&models.PropertyRate{}, &models.PropertyBlock{}} {
+			if err := tx.Where("property_id = ?", p.ID).Delete(child).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&p).Error
+	})
+	if err != nil {
+		return err
+	}
+	s.Images.DeleteBlobs(ctx, blobs...)
+	return nil
+}
+
+// ReviewQueueOrder is the review queue's order, oldest submission first.
+var ReviewQueueOrder = []utils.SortColumn{
+	{Column: "properties.submitted_at"},
+	{Column: "properties.id"},
+}
+
+// ReviewQueue lists listings awaiting review. page orders and limits the
+// list, by ReviewQueueOrder.
+func (s *ListingService) ReviewQueue(ctx context.Context, page func(*gorm.DB) *gorm.DB) ([]models.Property, error) {
+	var props []models.Property
+	err := s.DB.WithContext(ctx).Preload("Images", ImagesInOrder).
+		Where("status = ?", models.PropertyPendingReview).
+		Scopes(page).Find(&props).Error
+	return props, err
+}
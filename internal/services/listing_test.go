@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+)
+
+func TestDeleteListingRemovesRatesAndBlocks(t *testing.T) {
+	db := testDB(t)
+	owner := uuid.New()
+	prop := models.Property{Title: "Ikoyi duplex", Status: models.PropertyDraft, OwnerID: &owner, Currency: "NGN"}
+	if err := db.Create(&prop).Error; err != nil {
+		t.Fatal(err)
+	}
+	day := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	for _, row := range []interface{}{
+		&models.PropertyRate{PropertyID: prop.ID, Label: "May", StartDate: day, EndDate: day.AddDate(0, 1, 0), NightlyPrice: models.NewMoney(100, "NGN"), Currency: "NGN"},
+		&models.PropertyBlock{PropertyID: prop.ID, StartDate: day, EndDate: day.AddDate(0, 0, 3)},
+	} {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := &ListingService{DB: db, Images: &ImageService{DB: db}}
+
+	if err := s.Delete(context.Background(), prop.ID, uuid.New()); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("delete by someone else: err = %v; want not found", err)
+	}
+	if err := s.DeleteAny(context.Background(), prop.ID); err != nil {
+		t.Fatal(err)
+	}
+	for name, model := range map[string]interface{}{
+		"property": &models.Property{}, "rates": &models.PropertyRate{}, "blocks": &models.PropertyBlock{},
+	} {
+		var n int64
+		col := "property_id"
+		if name == "property" {
+			col = "id"
+		}
+		if err := db.Model(model).Where(col+" = ?", prop.ID).Count(&n).Error; err != nil {
+			t.Fatal(err)
+		}
+		if n != 0 {
+			t.Errorf("%d %s left after delete", n, name)
+		}
+	}
+}
+
+func TestDeleteListingKeepsBookedListings(t *testing.T) {
+	db := testDB(t)
+	// a stay that is long over still has a guest's receipt behind it
+	_, _, b := paymentFixture(t, db, models.NewMoney(5000000, "NGN"))
+	if err := db.Model(b).Update("status", "confirmed").Error; err != nil {
+		t.Fatal(err)
+	}
+	s := &ListingService{DB: db, Images: &ImageService{DB: db}}
+
+	if err := s.DeleteAny(context.Background(), b.PropertyID); !errors.Is(err, ErrListingHasBookings) {
+		t.Fatalf("delete of a booked listing: err = %v; want ErrListingHasBookings", err)
+	}
+	var prop models.Property
+	reload(t, db, &prop, b.PropertyID)
+}
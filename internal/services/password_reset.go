@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+	"github.com/olamideolayemi/realestate-backend/internal/utils"
+)
+
+var (
+	ErrInvalidResetCode = errors.New("invalid or expired reset code")
+	ErrTooManyAttempts  = errors.New("too many attempts; request a new code")
+)
+
+const (
+	DefaultResetCodeTTL      = 15 * time.Minute
+	DefaultResetMaxAttempts  = 5
+	DefaultResetResendWindow = time.Minute
+)
+
+// PasswordResetService resets forgotten passwords with emailed one-time
+// codes. A code works once, expires after CodeTTL and is burnt after
+// MaxAttempts wrong guesses.
+type PasswordResetService struct {
+	DB       *gorm.DB
+	Sessions *SessionService
+	// CodeTTL, MaxAttempts and ResendWindow default to the Default* values.
+	CodeTTL      time.Duration
+	MaxAttempts  int
+	ResendWindow time.Duration
+	// Now and SendMail default to time.Now and utils.SendMail.
+	Now      func() time.Time
+	SendMail func(to, subject, body string) error
+}
+
+func (s *PasswordResetService) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+func (s *PasswordResetService) sendMail(to, subject, body string) error {
+	if s.SendMail != nil {
+		return s.SendMail(to, subject, body)
+	}
+	return utils.SendMail(to, subject, body)
+}
+
+func (s *PasswordResetService) codeTTL() time.Duration {
+	if s.CodeTTL > 0 {
+		return s.CodeTTL
+	}
+	return DefaultResetCodeTTL
+}
+
+func (s *PasswordResetService) maxAttempts() int {
+	if s.MaxAttempts > 0 {
+		return s.MaxAttempts
+	}
+	return DefaultResetMaxAttempts
+}
+
+func (s *PasswordResetService) resendWindow() time.Duration {
+	if s.ResendWindow > 0 {
+		return s.ResendWindow
+	}
+	return DefaultResetResendWindow
+}
+
+// Request emails a reset code to email if it belongs to a verified user.
+// It reports nothing about whether the account exists, so callers can give
+// every requester the same answer.
+func (s *PasswordResetService) Request(ctx context.Context, email string) error {
+	db := s.DB.WithContext(ctx)
+	var u models.User
+	if err := db.Where("email = ?", email).First(&u).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if !u.IsVerified {
+		return nil
+	}
+
+	now := s.now()
+	var existing models.EmailVerification
+	err := db.Where("email = ? AND purpose = ?", email, models.PurposePasswordReset).First(&existing).Error
+	if err == nil && now.Sub(existing.CreatedAt) < s.resendWindow() && now.Before(existing.ExpiresAt) {
+		// a code was just sent; don't let the endpoint be used to spam inboxes
+		return nil
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	code := utils.GenerateOTP()
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("email = ? AND purpose = ?", email, models.PurposePasswordReset).
+			Delete(&models.EmailVerification{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.EmailVerification{
+			Email:     email,
+			Purpose:   models.PurposePasswordReset,
+			Code:      code,
+			ExpiresAt: now.Add(s.codeTTL()),
+			CreatedAt: now,
+			UpdatedAt: now,
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("<p>Your password reset code is <b>%s</b>. It expires in %d minutes.</p>"+
+		"<p>If you didn't ask to reset your password, you can ignore this email.</p>",
+		code, int(s.codeTTL().Minutes()))
+	if err := s.sendMail(email, "Reset your password", body); err != nil {
+		fmt.Println("Mail error:", err)
+	}
+	return nil
+}
+
+// Reset sets a new password when code matches the live reset code for
+// email and, in the same transaction, logs the user out everywhere. Wrong
+// codes count towards the attempt limit.
+func (s *PasswordResetService) Reset(ctx context.Context, email, code, newPassword string) error {
+	hashed, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	var user models.User
+	var failure error
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var rec models.EmailVerification
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("email = ? AND purpose = ?", email, models.PurposePasswordReset).
+			First(&rec).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				failure = ErrInvalidResetCode
+				return nil
+			}
+			return err
+		}
+		burn := func() error {
+			return tx.Unscoped().Delete(&rec).Error
+		}
+
+		switch {
+		case !s.now().Before(rec.ExpiresAt):
+			failure = ErrInvalidResetCode
+			return burn()
+		case rec.Attempts >= s.maxAttempts():
+			failure = ErrTooManyAttempts
+			return burn()
+		case subtle.ConstantTimeCompare([]byte(rec.Code), []byte(code)) != 1:
+			failure = ErrInvalidResetCode
+			if rec.Attempts+1 >= s.maxAttempts() {
+				failure = ErrTooManyAttempts
+				return burn()
+			}
+			return tx.Model(&rec).Update("attempts", gorm.Expr("attempts + 1")).Error
+		}
+
+		if err := tx.Where("email = ?", email).First(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				failure = ErrInvalidResetCode
+				return burn()
+			}
+			return err
+		}
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"password_hash": hashed,
+			"updated_at":    s.now(),
+		}).Error; err != nil {
+			return err
+		}
+		if err := s.Sessions.revokeUser(tx, user.ID, RevokePasswordReset); err != nil {
+			return err
+		}
+		return burn()
+	})
+	if err != nil {
+		return err
+	}
+	// failed guesses are committed above so they count even though we error
+	if failure != nil {
+		return failure
+	}
+
+	body := fmt.Sprintf("<p>Hi %s,<br>Your password was just changed and you have been signed out of all devices.</p>"+
+		"<p>If this wasn't you, reset your password again right away.</p>", user.Name)
+	if err := s.sendMail(user.Email, "Your password was changed", body); err != nil {
+		fmt.Println("Mail error:", err)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+	"github.com/olamideolayemi/realestate-backend/internal/utils"
+)
+
+var resetCodeRe = regexp.MustCompile(`<b>(\d+)</b>`)
+
+// resetFixture is a verified user with a live session and a reset code
+// requested at the time *now points to.
+func resetFixture(t *testing.T, db *gorm.DB) (s *PasswordResetService, now *time.Time, u *models.User, session *TokenPair, code string) {
+	t.Helper()
+	sessions, clock, u, session := sessionFixture(t, db)
+	s = &PasswordResetService{
+		DB:       db,
+		Sessions: sessions,
+		Now:      func() time.Time { return *clock },
+		SendMail: func(to, subject, body string) error {
+			if m := resetCodeRe.FindStringSubmatch(body); m != nil {
+				code = m[1]
+			}
+			return nil
+		},
+	}
+	if err := s.Request(context.Background(), u.Email); err != nil {
+		t.Fatal(err)
+	}
+	if code == "" {
+		t.Fatal("no reset code was mailed")
+	}
+	return s, clock, u, session, code
+}
+
+func TestResetChangesPasswordAndRevokesSessions(t *testing.T) {
+	db := testDB(t)
+	s, _, u, session, code := resetFixture(t, db)
+
+	if err := s.Reset(context.Background(), u.Email, code, "n3w-passw0rd"); err != nil {
+		t.Fatal(err)
+	}
+	var got models.User
+	reload(t, db, &got, u.ID)
+	if !utils.CheckPasswordHash("n3w-passw0rd", got.PasswordHash) {
+		t.Error("password was not changed")
+	}
+	if err := ValidateSession(db, u.ID, session.SessionID.String()); !errors.Is(err, ErrSessionRevoked) {
+		t.Errorf("ValidateSession after reset: err = %v; want ErrSessionRevoked", err)
+	}
+	if _, err := s.Sessions.Refresh(context.Background(), session.RefreshToken, ClientInfo{}); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("refresh after reset: err = %v; want ErrInvalidRefreshToken", err)
+	}
+
+	// the code works once
+	if err := s.Reset(context.Background(), u.Email, code, "an0ther-one"); !errors.Is(err, ErrInvalidResetCode) {
+		t.Errorf("second use of the code: err = %v; want ErrInvalidResetCode", err)
+	}
+	reload(t, db, &got, u.ID)
+	if !utils.CheckPasswordHash("n3w-passw0rd", got.PasswordHash) {
+		t.Error("second use of the code changed the password")
+	}
+}
+
+func TestResetRejectsExpiredCode(t *testing.T) {
+	db := testDB(t)
+	s, now, u, session, code := resetFixture(t, db)
+
+	*now = now.Add(DefaultResetCodeTTL)
+	if err := s.Reset(context.Background(), u.Email, code, "n3w-passw0rd"); !errors.Is(err, ErrInvalidResetCode) {
+		t.Fatalf("expired code: err = %v; want ErrInvalidResetCode", err)
+	}
+	var got models.User
+	reload(t, db, &got, u.ID)
+	if got.PasswordHash != "x" {
+		t.Error("expired code changed the password")
+	}
+	if err := ValidateSession(db, u.ID, session.SessionID.String()); err != nil {
+		t.Errorf("expired code revoked sessions: %v", err)
+	}
+}
+
+func TestResetBurnsCodeAfterTooManyAttempts(t *testing.T) {
+	db := testDB(t)
+	s, _, u, _, code := resetFixture(t, db)
+	s.MaxAttempts = 3
+	ctx := context.Background()
+
+	for i := 1; i < s.MaxAttempts; i++ {
+		if err := s.Reset(ctx, u.Email, "wrong!", "n3w-passw0rd"); !errors.Is(err, ErrInvalidResetCode) {
+			t.Fatalf("wrong guess %d: err = %v; want ErrInvalidResetCode", i, err)
+		}
+	}
+	if err := s.Reset(ctx, u.Email, "wrong!", "n3w-passw0rd"); !errors.Is(err, ErrTooManyAttempts) {
+		t.Fatalf("last wrong guess: err = %v; want ErrTooManyAttempts", err)
+	}
+	// the right code is no good once the code is burnt
+	if err := s.Reset(ctx, u.Email, code, "n3w-passw0rd"); !errors.Is(err, ErrInvalidResetCode) {
+		t.Errorf("right code after the limit: err = %v; want ErrInvalidResetCode", err)
+	}
+	var got models.User
+	reload(t, db, &got, u.ID)
+	if got.PasswordHash != "x" {
+		t.Error("password changed after the attempt limit")
+	}
+}
@@ -1 +1,292 @@
 package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+)
+
+var (
+	ErrInvalidSignature  = errors.New("invalid webhook signature")
+	ErrBookingNotPayable = errors.New("booking is not awaiting payment")
+	ErrUnknownPayment    = errors.New("unknown payment reference")
+	ErrAmountMismatch    = errors.New("paid amount does not match booking total")
+)
+
+// ChargeRequest asks a gateway to start collecting a payment.
+type ChargeRequest struct {
+	Reference   string
+	Email       string
+	Amount      models.Money
+	CallbackURL string
+	Metadata    map[string]string
+}
+
+// Charge is a gateway's view of a payment.
+type Charge struct {
+	Reference        string
+	Status           string // pending|success|failed
+	Amount           models.Money
+	AuthorizationURL string
+	AccessCode       string
+	PaidAt           *time.Time
+}
+
+// Refund is a gateway's view of a refund.
+type Refund struct {
+	Reference string
+	Amount    models.Money
+	Status    string
+}
+
+// WebhookEvent is a verified gateway notification about a charge.
+type WebhookEvent struct {
+	Event     string       `json:"event"`
+	Reference string       `json:"reference"`
+	Status    string       `json:"status"`
+	Amount    models.Money `json:"amount"`
+}
+
+// PaymentGateway is implemented by each payment provider.
+type PaymentGateway interface {
+	Name() string
+	Initialize(ctx context.Context, req ChargeRequest) (*Charge, error)
+	Verify(ctx context.Context, reference string) (*Charge, error)
+	Refund(ctx context.Context, reference string, amount models.Money) (*Refund, error)
+	// ParseWebhook authenticates a webhook delivery and decodes it, returning
+	// ErrInvalidSignature when it was not sent by the gateway.
+	ParseWebhook(body []byte, header http.Header) (*WebhookEvent, error)
+}
+
+// NewPaymentGatewayFromEnv returns the Paystack gateway when
+// PAYSTACK_SECRET_KEY is set. The in-memory fake is only used when asked for
+// with PAYMENT_GATEWAY=fake, and then signs webhooks with
+// FAKE_GATEWAY_SECRET; anything else is an error, since the webhook route is
+// public.
+func NewPaymentGatewayFromEnv() (PaymentGateway, error) {
+	if key := os.Getenv("PAYSTACK_SECRET_KEY"); key != "" {
+		return &PaystackGateway{SecretKey: key, BaseURL: os.Getenv("PAYSTACK_BASE_URL")}, nil
+	}
+	if os.Getenv("PAYMENT_GATEWAY") != "fake" {
+		return nil, errors.New("PAYSTACK_SECRET_KEY not set (set PAYMENT_GATEWAY=fake for local development)")
+	}
+	secret := os.Getenv("FAKE_GATEWAY_SECRET")
+	if secret == "" {
+		return nil, errors.New("PAYMENT_GATEWAY=fake needs FAKE_GATEWAY_SECRET to sign webhooks")
+	}
+	log.Println("PAYMENT_GATEWAY=fake; using fake payment gateway")
+	return NewFakeGateway(secret), nil
+}
+
+// PaymentService ties gateway charges to bookings.
+type PaymentService struct {
+	DB           *gorm.DB
+	Gateway      PaymentGateway
+	Availability *AvailabilityService
+	// CallbackURL is where the gateway sends the guest after checkout.
+	CallbackURL string
+}
+
+// Checkout starts (or resumes) payment for a pending booking and returns the
+// payment holding the gateway's checkout reference.
+func (s *PaymentService) Checkout(ctx context.Context, booking *models.Booking, email string) (*models.Payment, error) {
+	if booking.Status != "pending" {
+		return nil, ErrBookingNotPayable
+	}
+
+	// reuse an open checkout for the same amount so retries don't pile up charges
+	var existing models.Payment
+	err := s.DB.Where("booking_id = ? AND status = ? AND amount_minor = ? AND currency = ?",
+		booking.ID, "initialized", booking.TotalAmount, booking.TotalAmount.Currency).
+		Order("created_at DESC").First(&existing).Error
+	if err == nil {
+		return &existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	ref := newPaymentReference()
+	charge, err := s.Gateway.Initialize(ctx, ChargeRequest{
+		Reference:   ref,
+		Email:       email,
+		Amount:      booking.TotalAmount,
+		CallbackURL: s.CallbackURL,
+		Metadata:    map[string]string{"booking_id": booking.ID.String()},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	p := models.Payment{
+		ID:               uuid.New(),
+		BookingID:        booking.ID,
+		Reference:        charge.Reference,
+		Gateway:          s.Gateway.Name(),
+		Amount:           booking.TotalAmount,
+		Currency:         booking.TotalAmount.Currency,
+		Status:           "initialized",
+		AuthorizationURL: charge.AuthorizationURL,
+		AccessCode:       charge.AccessCode,
+		CreatedAt:        time.Now(),
+		UpdatedAt:        time.Now(),
+	}
+	if err := s.DB.Create(&p).Error; err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// HandleWebhook applies a verified gateway event. A successful charge marks
+// the payment paid and confirms its booking; a failed one marks it failed;
+// anything else (a pending or abandoned checkout the guest can still
+// finish) is ignored. A charge that can no longer confirm its booking is
+// refunded, or left refund_due when the refund fails. Replays of the same
+// event are no-ops.
+func (s *PaymentService) HandleWebhook(ctx context.Context, ev *WebhookEvent) error {
+	switch ev.Status {
+	case "success":
+	case "failed":
+		return s.DB.Model(&models.Payment{}).
+			Where("reference = ? AND status = ?", ev.Reference, "initialized").
+			Updates(map[string]interface{}{"status": "failed", "updated_at": time.Now()}).Error
+	default:
+		return nil
+	}
+
+	var p models.Payment
+	refund := false
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&p, "reference = ?", ev.Reference).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUnknownPayment
+			}
+			return err
+		}
+		if p.Status == "success" || p.Status == "refunded" || p.Status == "refund_due" {
+			return nil
+		}
+		if ev.Amount.Amount != p.Amount.Amount || !strings.EqualFold(ev.Amount.Currency, p.Currency) {
+			return ErrAmountMismatch
+		}
+
+		now := time.Now()
+		p.Status = "success"
+		p.PaidAt = &now
+		p.UpdatedAt = now
+		if err := tx.Save(&p).Error; err != nil {
+			return err
+		}
+
+		var b models.Booking
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&b, "id = ?", p.BookingID).Error; err != nil {
+			return err
+		}
+		if b.Status == "cancelled" && b.CancellationReason == ExpiredHoldReason {
+			// paid just after the hold lapsed: reinstate if nobody took the
+			// dates, holding the property like new bookings do
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+				First(&models.Property{}, "id = ?", b.PropertyID).Error; err != nil {
+				return err
+			}
+			bookings, blocks, err := s.Availability.Conflicts(tx, b.PropertyID, b.Checkin, b.Checkout)
+			if err != nil {
+				return err
+			}
+			if bookings > 0 || blocks > 0 {
+				log.Printf("payment %s arrived for expired booking %s whose dates are taken; refunding", p.Reference, b.ID)
+				refund = true
+				return nil
+			}
+		} else if b.Status != "pending" {
+			log.Printf("payment %s succeeded for booking %s in status %q; refunding", p.Reference, b.ID, b.Status)
+			refund = true
+			return nil
+		}
+		if p.Amount.Amount != b.TotalAmount.Amount || !strings.EqualFold(p.Currency, b.TotalAmount.Currency) {
+			// the booking was changed after this checkout was opened
+			log.Printf("payment %s of %s no longer covers booking %s total %s; refunding", p.Reference, p.Amount, b.ID, b.TotalAmount)
+			refund = true
+			return nil
+		}
+		return tx.Model(&b).Updates(map[string]interface{}{
+			"status":              "confirmed",
+			"payment_ref":         p.Reference,
+			"cancellation_reason": "",
+			"cancelled_at":        nil,
+			"updated_at":          now,
+		}).Error
+	})
+	if err != nil || !refund {
+		return err
+	}
+	if _, err := s.Refund(ctx, p.Reference, p.Amount); err != nil {
+		log.Printf("refund of payment %s failed: %v; marked refund_due", p.Reference, err)
+		return s.DB.WithContext(ctx).Model(&models.Payment{}).Where("id = ?", p.ID).
+			Updates(map[string]interface{}{"status": "refund_due", "updated_at": time.Now()}).Error
+	}
+	return nil
+}
+
+// Refund returns amount of a successful (or refund_due) payment to the guest
+// and returns the gateway's refund reference. The payment row stays locked
+// until the refund is recorded, so concurrent refunds of one payment (say a
+// guest and staff cancelling at once) can't both pass the balance check.
+func (s *PaymentService) Refund(ctx context.Context, reference string, amount models.Money) (string, error) {
+	var ref string
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var p models.Payment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&p, "reference = ?", reference).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUnknownPayment
+			}
+			return err
+		}
+		if p.Status != "success" && p.Status != "refunded" && p.Status != "refund_due" {
+			return ErrUnknownPayment
+		}
+		refunded, err := p.RefundedAmount.Add(amount)
+		if err != nil || !amount.IsPositive() || refunded.Amount > p.Amount.Amount {
+			return ErrAmountMismatch
+		}
+
+		r, err := s.Gateway.Refund(ctx, reference, amount)
+		if err != nil {
+			return err
+		}
+		ref = r.Reference
+		updates := map[string]interface{}{"refunded_amount_minor": refunded, "updated_at": time.Now()}
+		if refunded.Amount == p.Amount.Amount {
+			updates["status"] = "refunded"
+		}
+		if err := tx.Model(&p).Updates(updates).Error; err != nil {
+			log.Printf("refund %s of payment %s was issued but not recorded: %v", r.Reference, reference, err)
+			return err
+		}
+		return nil
+	})
+	return ref, err
+}
+
+func newPaymentReference() string {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "bk_" + uuid.NewString()
+	}
+	return "bk_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+)
+
+// FakeGateway is an in-memory PaymentGateway for tests and local development.
+// Webhooks are signed like Paystack's, with Secret as the key.
+type FakeGateway struct {
+	Secret string
+
+	mu      sync.Mutex
+	charges map[string]*Charge
+	refunds []Refund
+}
+
+func NewFakeGateway(secret string) *FakeGateway {
+	return &FakeGateway{Secret: secret, charges: make(map[string]*Charge)}
+}
+
+func (g *FakeGateway) Name() string { return "fake" }
+
+func (g *FakeGateway) Initialize(_ context.Context, req ChargeRequest) (*Charge, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.charges[req.Reference]; ok {
+		return nil, fmt.Errorf("duplicate reference %q", req.Reference)
+	}
+	ch := &Charge{
+		Reference:        req.Reference,
+		Status:           "pending",
+		Amount:           req.Amount,
+		AuthorizationURL: "https://checkout.fake/" + req.Reference,
+		AccessCode:       req.Reference,
+	}
+	g.charges[req.Reference] = ch
+	out := *ch
+	return &out, nil
+}
+
+func (g *FakeGateway) Verify(_ context.Context, reference string) (*Charge, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	ch, ok := g.charges[reference]
+	if !ok {
+		return nil, ErrUnknownPayment
+	}
+	out := *ch
+	return &out, nil
+}
+
+func (g *FakeGateway) Refund(_ context.Context, reference string, amount models.Money) (*Refund, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	ch, ok := g.charges[reference]
+	if !ok || ch.Status != "success" {
+		return nil, ErrUnknownPayment
+	}
+	if !amount.IsPositive() {
+		amount = ch.Amount
+	}
+	r := Refund{Reference: reference, Amount: amount, Status: "processed"}
+	g.refunds = append(g.refunds, r)
+	return &r, nil
+}
+
+func (g *FakeGateway) ParseWebhook(body []byte, header http.Header) (*WebhookEvent, error) {
+	if !hmac.Equal([]byte(g.Sign(body)), []byte(header.Get("x-paystack-signature"))) {
+		return nil, ErrInvalidSignature
+	}
+	var ev WebhookEvent
+	if err := json.Unmarshal(body, &ev); err != nil {
+		return nil, fmt.Errorf("invalid webhook payload: %w", err)
+	}
+	return &ev, nil
+}
+
+// Sign returns the signature header value for a webhook body.
+func (g *FakeGateway) Sign(body []byte) string {
+	mac := hmac.New(sha512.New, []byte(g.Secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Complete marks a charge as paid and returns the signed webhook that the
+// real gateway would deliver.
+func (g *FakeGateway) Complete(reference string) (body []byte, signature string, err error) {
+	g.mu.Lock()
+	ch, ok := g.charges[reference]
+	if ok {
+		now := time.Now()
+		ch.Status = "success"
+		ch.PaidAt = &now
+	}
+	g.mu.Unlock()
+	if !ok {
+		return nil, "", ErrUnknownPayment
+	}
+	body, err = json.Marshal(WebhookEvent{
+		Event:     "charge.success",
+		Reference: ch.Reference,
+		Status:    "success",
+		Amount:    ch.Amount,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return body, g.Sign(body), nil
+}
+
+// Refunds returns the refunds issued so far.
+func (g *FakeGateway) Refunds() []Refund {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]Refund(nil), g.refunds...)
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+)
+
+const paystackDefaultBaseURL = "https://api.paystack.co"
+
+// PaystackGateway talks to the Paystack transactions API. Amounts are sent in
+// the currency's minor unit (kobo for NGN).
+type PaystackGateway struct {
+	SecretKey  string
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+type paystackResponse struct {
+	Status  bool            `json:"status"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+type paystackTransaction struct {
+	Reference        string `json:"reference"`
+	Status           string `json:"status"`
+	Amount           int64  `json:"amount"`
+	Currency         string `json:"currency"`
+	AuthorizationURL string `json:"authorization_url"`
+	AccessCode       string `json:"access_code"`
+	PaidAt           string `json:"paid_at"`
+}
+
+func (g *PaystackGateway) Name() string { return "paystack" }
+
+func (g *PaystackGateway) Initialize(ctx context.Context, req ChargeRequest) (*Charge, error) {
+	body := map[string]interface{}{
+		"reference": req.Reference,
+		"email":     req.Email,
+		"amount":    req.Amount.Amount,
+		"currency":  req.Amount.Currency,
+		"metadata":  req.Metadata,
+	}
+	if req.CallbackURL != "" {
+		body["callback_url"] = req.CallbackURL
+	}
+	var tx paystackTransaction
+	if err := g.do(ctx, http.MethodPost, "/transaction/initialize", body, &tx); err != nil {
+		return nil, err
+	}
+	return &Charge{
+		Reference:        tx.Reference,
+		Status:           "pending",
+		Amount:           req.Amount,
+		AuthorizationURL: tx.AuthorizationURL,
+		AccessCode:       tx.AccessCode,
+	}, nil
+}
+
+func (g *PaystackGateway) Verify(ctx context.Context, reference string) (*Charge, error) {
+	var tx paystackTransaction
+	if err := g.do(ctx, http.MethodGet, "/transaction/verify/"+url.PathEscape(reference), nil, &tx); err != nil {
+		return nil, err
+	}
+	ch := &Charge{
+		Reference: tx.Reference,
+		Status:    paystackStatus(tx.Status),
+		Amount:    models.NewMoney(tx.Amount, tx.Currency),
+	}
+	if t, err := time.Parse(time.RFC3339, tx.PaidAt); err == nil {
+		ch.PaidAt = &t
+	}
+	return ch, nil
+}
+
+func (g *PaystackGateway) Refund(ctx context.Context, reference string, amount models.Money) (*Refund, error) {
+	body := map[string]interface{}{"transaction": reference}
+	if amount.IsPositive() {
+		body["amount"] = amount.Amount
+	}
+	var out struct {
+		Status string `json:"status"`
+		Amount int64  `json:"amount"`
+	}
+	if err := g.do(ctx, http.MethodPost, "/refund", body, &out); err != nil {
+		return nil, err
+	}
+	return &Refund{Reference: reference, Amount: models.NewMoney(out.Amount, amount.Currency), Status: out.Status}, nil
+}
+
+// ParseWebhook checks the x-paystack-signature header, an HMAC-SHA512 of the
+// raw body keyed with the secret key.
+func (g *PaystackGateway) ParseWebhook(body []byte, header http.Header) (*WebhookEvent, error) {
+	mac := hmac.New(sha512.New, []byte(g.SecretKey))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(header.Get("x-paystack-signature"))) {
+		return nil, ErrInvalidSignature
+	}
+
+	var payload struct {
+		Event string              `json:"event"`
+		Data  paystackTransaction `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid webhook payload: %w", err)
+	}
+	return &WebhookEvent{
+		Event:     payload.Event,
+		Reference: payload.Data.Reference,
+		Status:    paystackStatus(payload.Data.Status),
+		Amount:    models.NewMoney(payload.Data.Amount, payload.Data.Currency),
+	}, nil
+}
+
+func (g *PaystackGateway) do(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	base := g.BaseURL
+	if base == "" {
+		base = paystackDefaultBaseURL
+	}
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, base+path, &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+g.SecretKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := g.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 15 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("paystack request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var pr paystackResponse
+	if err := json.NewDecoder(resp.Body).Decode(&pr); err != nil {
+		return fmt.Errorf("paystack: invalid response (HTTP %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode >= 300 || !pr.Status {
+		return fmt.Errorf("paystack: %s (HTTP %d)", pr.Message, resp.StatusCode)
+	}
+	if out != nil && len(pr.Data) > 0 {
+		return json.Unmarshal(pr.Data, out)
+	}
+	return nil
+}
+
+func paystackStatus(s string) string {
+	switch s {
+	case "success":
+		return "success"
+	case "failed", "reversed":
+		return "failed"
+	default:
+		return "pending"
+	}
+}
@@ -0,0 +1,241 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+)
+
+func TestFakeGatewayRejectsBadSignatures(t *testing.T) {
+	g := NewFakeGateway("whsec")
+	if _, err := g.Initialize(context.Background(), ChargeRequest{Reference: "bk_1", Amount: models.NewMoney(5000, "NGN")}); err != nil {
+		t.Fatal(err)
+	}
+	body, sig, err := g.Complete("bk_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := func(sig string) http.Header {
+		h := http.Header{}
+		h.Set("x-paystack-signature", sig)
+		return h
+	}
+
+	ev, err := g.ParseWebhook(body, header(sig))
+	if err != nil || ev.Reference != "bk_1" || ev.Status != "success" || ev.Amount != models.NewMoney(5000, "NGN") {
+		t.Fatalf("ParseWebhook(signed) = %+v, %v", ev, err)
+	}
+	tampered := append([]byte(nil), body...)
+	tampered[len(tampered)-2] = '9'
+	for name, tc := range map[string]struct {
+		body []byte
+		h    http.Header
+	}{
+		"tampered body": {tampered, header(sig)},
+		"no signature":  {body, http.Header{}},
+		"other secret":  {body, header(NewFakeGateway("other").Sign(body))},
+	} {
+		if _, err := g.ParseWebhook(tc.body, tc.h); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: err = %v; want ErrInvalidSignature", name, err)
+		}
+	}
+}
+
+// paymentFixture is a pending booking of total on a fresh property, and a
+// PaymentService charging it through a FakeGateway.
+func paymentFixture(t *testing.T, db *gorm.DB, total models.Money) (*PaymentService, *FakeGateway, *models.Booking) {
+	t.Helper()
+	prop := models.Property{Title: "Lekki flat", Status: models.PropertyPublished, Currency: total.Currency}
+	if err := db.Create(&prop).Error; err != nil {
+		t.Fatal(err)
+	}
+	b := models.Booking{
+		PropertyID:  prop.ID,
+		Checkin:     time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+		Checkout:    time.Date(2026, 4, 3, 0, 0, 0, 0, time.UTC),
+		Nights:      2,
+		Guests:      1,
+		TotalAmount: total,
+		Currency:    total.Currency,
+		Status:      "pending",
+	}
+	if err := db.Create(&b).Error; err != nil {
+		t.Fatal(err)
+	}
+	g := NewFakeGateway("whsec")
+	return &PaymentService{DB: db, Gateway: g, Availability: &AvailabilityService{DB: db}}, g, &b
+}
+
+// pay completes ref at the gateway and delivers its webhook.
+func pay(t *testing.T, s *PaymentService, g *FakeGateway, ref string) error {
+	t.Helper()
+	body, sig, err := g.Complete(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := http.Header{}
+	h.Set("x-paystack-signature", sig)
+	ev, err := g.ParseWebhook(body, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s.HandleWebhook(context.Background(), ev)
+}
+
+func reload(t *testing.T, db *gorm.DB, dest interface{}, id interface{}) {
+	t.Helper()
+	if err := db.First(dest, "id = ?", id).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckoutReusesOpenCheckout(t *testing.T) {
+	db := testDB(t)
+	s, _, b := paymentFixture(t, db, models.NewMoney(5000000, "NGN"))
+	ctx := context.Background()
+
+	first, err := s.Checkout(ctx, b, "guest@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Status != "initialized" || first.Amount != b.TotalAmount || first.AuthorizationURL == "" {
+		t.Errorf("checkout = %+v; want an initialized payment of %s", first, b.TotalAmount)
+	}
+	again, err := s.Checkout(ctx, b, "guest@example.com")
+	if err != nil || again.Reference != first.Reference {
+		t.Errorf("second checkout = %+v, %v; want reference %s reused", again, err, first.Reference)
+	}
+
+	b.Status = "confirmed"
+	if _, err := s.Checkout(ctx, b, "guest@example.com"); !errors.Is(err, ErrBookingNotPayable) {
+		t.Errorf("checkout of a confirmed booking: err = %v; want ErrBookingNotPayable", err)
+	}
+}
+
+func TestWebhookConfirmsOnce(t *testing.T) {
+	db := testDB(t)
+	s, g, b := paymentFixture(t, db, models.NewMoney(5000000, "NGN"))
+	p, err := s.Checkout(context.Background(), b, "guest@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := pay(t, s, g, p.Reference); err != nil {
+		t.Fatal(err)
+	}
+	var paid models.Payment
+	reload(t, db, &paid, p.ID)
+	if paid.Status != "success" || paid.PaidAt == nil {
+		t.Fatalf("payment after webhook = %+v; want success", paid)
+	}
+	var got models.Booking
+	reload(t, db, &got, b.ID)
+	if got.Status != "confirmed" || got.PaymentRef != p.Reference {
+		t.Fatalf("booking after webhook: status %q ref %q; want confirmed, %s", got.Status, got.PaymentRef, p.Reference)
+	}
+
+	// gateways retry deliveries; a replay changes nothing
+	if err := pay(t, s, g, p.Reference); err != nil {
+		t.Fatalf("duplicate webhook: %v", err)
+	}
+	var replayed models.Payment
+	reload(t, db, &replayed, p.ID)
+	if replayed.Status != "success" || !replayed.PaidAt.Equal(*paid.PaidAt) {
+		t.Errorf("duplicate webhook changed the payment: %+v", replayed)
+	}
+	if r := g.Refunds(); len(r) != 0 {
+		t.Errorf("duplicate webhook refunded %v", r)
+	}
+}
+
+func TestWebhookRejectsWrongAmount(t *testing.T) {
+	db := testDB(t)
+	s, _, b := paymentFixture(t, db, models.NewMoney(5000000, "NGN"))
+	p, err := s.Checkout(context.Background(), b, "guest@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, amount := range []models.Money{models.NewMoney(500000, "NGN"), models.NewMoney(5000000, "USD")} {
+		ev := &WebhookEvent{Event: "charge.success", Reference: p.Reference, Status: "success", Amount: amount}
+		if err := s.HandleWebhook(context.Background(), ev); !errors.Is(err, ErrAmountMismatch) {
+			t.Errorf("webhook for %s: err = %v; want ErrAmountMismatch", amount, err)
+		}
+	}
+	var got models.Payment
+	reload(t, db, &got, p.ID)
+	var booking models.Booking
+	reload(t, db, &booking, b.ID)
+	if got.Status != "initialized" || booking.Status != "pending" {
+		t.Errorf("after mismatched webhooks: payment %q booking %q; want initialized, pending", got.Status, booking.Status)
+	}
+}
+
+func TestWebhookRefundsCheckoutForOldTotal(t *testing.T) {
+	db := testDB(t)
+	s, g, b := paymentFixture(t, db, models.NewMoney(5000000, "NGN"))
+	p, err := s.Checkout(context.Background(), b, "guest@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the booking was moved to pricier dates after checkout opened
+	if err := db.Model(b).Update("total_amount_minor", 6500000).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := pay(t, s, g, p.Reference); err != nil {
+		t.Fatal(err)
+	}
+	var got models.Payment
+	reload(t, db, &got, p.ID)
+	if got.Status != "refunded" || got.RefundedAmount.Amount != p.Amount.Amount {
+		t.Errorf("payment = %+v; want refunded in full", got)
+	}
+	var booking models.Booking
+	reload(t, db, &booking, b.ID)
+	if booking.Status != "pending" {
+		t.Errorf("booking status %q; want pending", booking.Status)
+	}
+	if r := g.Refunds(); len(r) != 1 || r[0].Amount != p.Amount {
+		t.Errorf("gateway refunds = %v; want one of %s", r, p.Amount)
+	}
+}
+
+func TestRefundNeverExceedsPayment(t *testing.T) {
+	db := testDB(t)
+	s, g, b := paymentFixture(t, db, models.NewMoney(5000000, "NGN"))
+	ctx := context.Background()
+	p, err := s.Checkout(ctx, b, "guest@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pay(t, s, g, p.Reference); err != nil {
+		t.Fatal(err)
+	}
+
+	part := models.NewMoney(3000000, "NGN")
+	if _, err := s.Refund(ctx, p.Reference, part); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Refund(ctx, p.Reference, part); !errors.Is(err, ErrAmountMismatch) {
+		t.Errorf("refund past the paid amount: err = %v; want ErrAmountMismatch", err)
+	}
+	rest := models.NewMoney(2000000, "NGN")
+	if _, err := s.Refund(ctx, p.Reference, rest); err != nil {
+		t.Fatal(err)
+	}
+	var got models.Payment
+	reload(t, db, &got, p.ID)
+	if got.Status != "refunded" || got.RefundedAmount.Amount != 5000000 {
+		t.Errorf("payment = %+v; want refunded in full", got)
+	}
+	if r := g.Refunds(); len(r) != 2 {
+		t.Errorf("gateway refunds = %v; want 2", r)
+	}
+}
@@ -0,0 +1,224 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+)
+
+const (
+	weeklyStayNights  = 7
+	monthlyStayNights = 28
+)
+
+// ErrRateCurrency means a seasonal rate was priced in another currency than
+// its property, e.g. before the property's currency was changed.
+var ErrRateCurrency = errors.New("a seasonal rate is not in the property's currency; update the rate")
+
+// PricingService prices stays from a property's base rate, seasonal rates,
+// weekend uplift, extra guests, length-of-stay discounts and fees.
+type PricingService struct {
+	DB *gorm.DB
+}
+
+// NightPrice is the price of a single night of a stay.
+type NightPrice struct {
+	Date        string       `json:"date"`
+	Price       models.Money `json:"price"`
+	Rate        string       `json:"rate"` // "base" or the seasonal rate's label
+	Weekend     bool         `json:"weekend"`
+	ExtraGuests int          `json:"extra_guests,omitempty"` // guests beyond the property's base_guests, charged per night
+}
+
+// QuoteLine is one itemised line of a quote.
+type QuoteLine struct {
+	Kind        string       `json:"kind"` // nightly|discount|cleaning_fee|caution_deposit
+	Description string       `json:"description"`
+	Date        *time.Time   `json:"date,omitempty"`
+	Amount      models.Money `json:"amount"`
+	Refundable  bool         `json:"refundable"`
+}
+
+// Quote is the itemised price of a stay, in the property's currency.
+type Quote struct {
+	PropertyID     uuid.UUID    `json:"property_id"`
+	Checkin        string       `json:"checkin"`
+	Checkout       string       `json:"checkout"`
+	Nights         int          `json:"nights"`
+	Guests         int          `json:"guests"`
+	Currency       string       `json:"currency"`
+	NightlyPrices  []NightPrice `json:"nightly_prices"`
+	Subtotal       models.Money `json:"subtotal"`
+	Discount       models.Money `json:"discount"`
+	CleaningFee    models.Money `json:"cleaning_fee"`
+	CautionDeposit models.Money `json:"caution_deposit"`
+	Total          models.Money `json:"total"`
+	Lines          []QuoteLine  `json:"line_items"`
+}
+
+// Quote prices a stay from checkin up to checkout. It does not check
+// availability; pass a transaction as db to price against locked rows.
+func (s *PricingService) Quote(db *gorm.DB, prop *models.Property, checkin, checkout time.Time, guests int) (*Quote, error) {
+	if !checkout.After(checkin) {
+		return nil, ErrInvalidRange
+	}
+
+	var rates []models.PropertyRate
+	if err := db.
+		Where("property_id = ? AND start_date < ? AND end_date > ?", prop.ID, checkout, checkin).
+		Order("priority DESC, created_at DESC").
+		Find(&rates).Error; err != nil {
+		return nil, err
+	}
+	return priceStay(prop, rates, checkin, checkout, guests)
+}
+
+// priceStay prices a stay given the seasonal rates that overlap it, highest
+// priority first.
+func priceStay(prop *models.Property, rates []models.PropertyRate, checkin, checkout time.Time, guests int) (*Quote, error) {
+	currency := PropertyCurrency(prop)
+	for _, r := range rates {
+		if !strings.EqualFold(r.Currency, currency) {
+			return nil, fmt.Errorf("%w: %q is in %s, the property in %s", ErrRateCurrency, r.Label, r.Currency, currency)
+		}
+	}
+	extraGuests := 0
+	if prop.BaseGuests > 0 && guests > prop.BaseGuests && prop.ExtraGuestFee.IsPositive() {
+		extraGuests = guests - prop.BaseGuests
+	}
+	money := func(minor int64) models.Money { return models.NewMoney(minor, currency) }
+	q := &Quote{
+		PropertyID:     prop.ID,
+		Checkin:        checkin.Format(DateLayout),
+		Checkout:       checkout.Format(DateLayout),
+		Guests:         guests,
+		Currency:       currency,
+		Subtotal:       money(0),
+		Discount:       money(0),
+		CleaningFee:    money(0),
+		CautionDeposit: money(0),
+	}
+
+	for d := checkin; d.Before(checkout); d = d.AddDate(0, 0, 1) {
+		night := NightPrice{Date: d.Format(DateLayout), Price: money(prop.Price.Amount), Rate: "base"}
+		for _, r := range rates {
+			if !d.Before(r.StartDate) && d.Before(r.EndDate) {
+				night.Price = money(r.NightlyPrice.Amount)
+				night.Rate = r.Label
+				break
+			}
+		}
+		var err error
+		if wd := d.Weekday(); prop.WeekendUplift > 0 && (wd == time.Friday || wd == time.Saturday) {
+			if night.Price, err = night.Price.Percent(100 + prop.WeekendUplift); err != nil {
+				return nil, err
+			}
+			night.Weekend = true
+		}
+		if extraGuests > 0 {
+			fee, err := money(prop.ExtraGuestFee.Amount).Mul(int64(extraGuests))
+			if err != nil {
+				return nil, err
+			}
+			if night.Price, err = night.Price.Add(fee); err != nil {
+				return nil, err
+			}
+			night.ExtraGuests = extraGuests
+		}
+		q.NightlyPrices = append(q.NightlyPrices, night)
+		if q.Subtotal, err = q.Subtotal.Add(night.Price); err != nil {
+			return nil, err
+		}
+
+		date := d
+		desc := "Night of " + night.Date
+		if night.Rate != "base" {
+			desc += " (" + night.Rate + ")"
+		}
+		if night.Weekend {
+			desc += " (weekend)"
+		}
+		if night.ExtraGuests > 0 {
+			desc += fmt.Sprintf(" (+%d guests)", night.ExtraGuests)
+		}
+		q.Lines = append(q.Lines, QuoteLine{Kind: "nightly", Description: desc, Date: &date, Amount: night.Price})
+	}
+	q.Nights = len(q.NightlyPrices)
+
+	var err error
+	switch {
+	case q.Nights >= monthlyStayNights && prop.MonthlyDiscount > 0:
+		if q.Discount, err = q.Subtotal.Percent(prop.MonthlyDiscount); err != nil {
+			return nil, err
+		}
+		q.Lines = append(q.Lines, QuoteLine{Kind: "discount", Description: fmt.Sprintf("Monthly stay discount (%d%%)", prop.MonthlyDiscount), Amount: q.Discount.Neg()})
+	case q.Nights >= weeklyStayNights && prop.WeeklyDiscount > 0:
+		if q.Discount, err = q.Subtotal.Percent(prop.WeeklyDiscount); err != nil {
+			return nil, err
+		}
+		q.Lines = append(q.Lines, QuoteLine{Kind: "discount", Description: fmt.Sprintf("Weekly stay discount (%d%%)", prop.WeeklyDiscount), Amount: q.Discount.Neg()})
+	}
+	if prop.CleaningFee.IsPositive() {
+		q.CleaningFee = money(prop.CleaningFee.Amount)
+		q.Lines = append(q.Lines, QuoteLine{Kind: "cleaning_fee", Description: "Cleaning fee", Amount: q.CleaningFee})
+	}
+	if prop.CautionDeposit.IsPositive() {
+		q.CautionDeposit = money(prop.CautionDeposit.Amount)
+		q.Lines = append(q.Lines, QuoteLine{Kind: "caution_deposit", Description: "Caution deposit (refundable)", Amount: q.CautionDeposit, Refundable: true})
+	}
+
+	q.Total = q.Subtotal
+	for _, add := range []models.Money{q.Discount.Neg(), q.CleaningFee, q.CautionDeposit} {
+		if q.Total, err = q.Total.Add(add); err != nil {
+			return nil, err
+		}
+	}
+	return q, nil
+}
+
+// PropertyCurrency is the currency a property is priced and charged in.
+func PropertyCurrency(prop *models.Property) string {
+	if prop.Currency == "" {
+		return "NGN"
+	}
+	return strings.ToUpper(prop.Currency)
+}
+
+// LineItems converts the quote into booking line items for bookingID.
+func (q *Quote) LineItems(bookingID uuid.UUID) []models.BookingLineItem {
+	items := make([]models.BookingLineItem, 0, len(q.Lines))
+	now := time.Now()
+	for _, l := range q.Lines {
+		items = append(items, models.BookingLineItem{
+			ID:          uuid.New(),
+			BookingID:   bookingID,
+			Kind:        l.Kind,
+			Description: l.Description,
+			Date:        l.Date,
+			Amount:      l.Amount,
+			Currency:    q.Currency,
+			Refundable:  l.Refundable,
+			CreatedAt:   now,
+		})
+	}
+	return items
+}
+
+// ReplaceLineItems stores the quote as the booking's line items, dropping
+// any it had before.
+func (s *PricingService) ReplaceLineItems(tx *gorm.DB, bookingID uuid.UUID, q *Quote) error {
+	if err := tx.Where("booking_id = ?", bookingID).Delete(&models.BookingLineItem{}).Error; err != nil {
+		return err
+	}
+	items := q.LineItems(bookingID)
+	if len(items) == 0 {
+		return nil
+	}
+	return tx.Create(&items).Error
+}
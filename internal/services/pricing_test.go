@@ -0,0 +1,189 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+)
+
+func date(s string) time.Time {
+	d, err := ParseDate(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func TestPriceStay(t *testing.T) {
+	ngn := func(minor int64) models.Money { return models.NewMoney(minor, "NGN") }
+	base := models.Property{Price: ngn(2000000), Currency: "NGN"} // ₦20,000 a night
+
+	for _, tc := range []struct {
+		name              string
+		prop              func(p *models.Property)
+		rates             []models.PropertyRate
+		checkin, checkout string // 2025-03-03 is a Monday
+		guests            int
+		nights            []int64 // nightly prices in kobo
+		discount, total   int64
+	}{
+		{
+			name:    "base rate",
+			checkin: "2025-03-03", checkout: "2025-03-06",
+			nights: []int64{2000000, 2000000, 2000000},
+			total:  6000000,
+		},
+		{
+			name:    "weekend uplift on Friday and Saturday",
+			prop:    func(p *models.Property) { p.WeekendUplift = 10 },
+			checkin: "2025-03-06", checkout: "2025-03-09",
+			nights: []int64{2000000, 2200000, 2200000},
+			total:  6400000,
+		},
+		{
+			name: "highest priority seasonal rate wins",
+			rates: []models.PropertyRate{
+				{Label: "Festival", StartDate: date("2025-03-05"), EndDate: date("2025-03-07"), NightlyPrice: ngn(3000000), Currency: "NGN", Priority: 2},
+				{Label: "Low season", StartDate: date("2025-03-01"), EndDate: date("2025-03-31"), NightlyPrice: ngn(1500000), Currency: "NGN", Priority: 1},
+			},
+			checkin: "2025-03-04", checkout: "2025-03-08",
+			nights: []int64{1500000, 3000000, 3000000, 1500000},
+			total:  9000000,
+		},
+		{
+			name:    "seasonal rate gets the weekend uplift too",
+			prop:    func(p *models.Property) { p.WeekendUplift = 20 },
+			rates:   []models.PropertyRate{{Label: "Easter", StartDate: date("2025-03-07"), EndDate: date("2025-03-08"), NightlyPrice: ngn(2500000), Currency: "NGN"}},
+			checkin: "2025-03-07", checkout: "2025-03-08",
+			nights: []int64{3000000},
+			total:  3000000,
+		},
+		{
+			name:    "weekly discount",
+			prop:    func(p *models.Property) { p.WeeklyDiscount = 10; p.MonthlyDiscount = 25 },
+			checkin: "2025-03-03", checkout: "2025-03-10",
+			nights:   []int64{2000000, 2000000, 2000000, 2000000, 2000000, 2000000, 2000000},
+			discount: 1400000, total: 12600000,
+		},
+		{
+			name:    "monthly discount replaces the weekly one",
+			prop:    func(p *models.Property) { p.WeeklyDiscount = 10; p.MonthlyDiscount = 25 },
+			checkin: "2025-03-01", checkout: "2025-03-29",
+			discount: 14000000, total: 42000000,
+		},
+		{
+			name:    "six nights get no weekly discount",
+			prop:    func(p *models.Property) { p.WeeklyDiscount = 10 },
+			checkin: "2025-03-03", checkout: "2025-03-09",
+			total: 12000000,
+		},
+		{
+			name: "uplift rounds half away from zero, fees are added whole",
+			prop: func(p *models.Property) {
+				p.Price = ngn(33333)
+				p.WeekendUplift = 15 // 38332.95 kobo
+				p.CleaningFee = ngn(150050)
+				p.CautionDeposit = ngn(1000000)
+			},
+			checkin: "2025-03-07", checkout: "2025-03-08",
+			nights: []int64{38333},
+			total:  38333 + 150050 + 1000000,
+		},
+		{
+			name:    "discount rounds to the kobo",
+			prop:    func(p *models.Property) { p.Price = ngn(33333); p.WeeklyDiscount = 5 },
+			checkin: "2025-03-03", checkout: "2025-03-10",
+			discount: 11667, total: 233331 - 11667, // 5% of 233331 is 11666.55
+		},
+		{
+			name:    "extra guests pay per night",
+			prop:    func(p *models.Property) { p.BaseGuests = 2; p.ExtraGuestFee = ngn(500000) },
+			checkin: "2025-03-03", checkout: "2025-03-05", guests: 4,
+			nights: []int64{3000000, 3000000},
+			total:  6000000,
+		},
+		{
+			name:    "guests within the base pay nothing extra",
+			prop:    func(p *models.Property) { p.BaseGuests = 2; p.ExtraGuestFee = ngn(500000) },
+			checkin: "2025-03-03", checkout: "2025-03-05", guests: 2,
+			nights: []int64{2000000, 2000000},
+			total:  4000000,
+		},
+		{
+			name:    "extra guest fees count towards the stay discount",
+			prop:    func(p *models.Property) { p.BaseGuests = 1; p.ExtraGuestFee = ngn(1000000); p.WeeklyDiscount = 10 },
+			checkin: "2025-03-03", checkout: "2025-03-10", guests: 2,
+			discount: 2100000, total: 18900000,
+		},
+	} {
+		prop := base
+		if tc.prop != nil {
+			tc.prop(&prop)
+		}
+		guests := tc.guests
+		if guests == 0 {
+			guests = 1
+		}
+		q, err := priceStay(&prop, tc.rates, date(tc.checkin), date(tc.checkout), guests)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if tc.nights != nil {
+			if len(q.NightlyPrices) != len(tc.nights) {
+				t.Errorf("%s: %d nights priced; want %d", tc.name, len(q.NightlyPrices), len(tc.nights))
+				continue
+			}
+			for i, n := range q.NightlyPrices {
+				if n.Price != ngn(tc.nights[i]) {
+					t.Errorf("%s: night %s = %v; want %v", tc.name, n.Date, n.Price, ngn(tc.nights[i]))
+				}
+			}
+		}
+		if q.Discount != ngn(tc.discount) || q.Total != ngn(tc.total) {
+			t.Errorf("%s: discount %v total %v; want %v and %v", tc.name, q.Discount, q.Total, ngn(tc.discount), ngn(tc.total))
+		}
+		// the itemised lines always add up to the total
+		sum := ngn(0)
+		for _, l := range q.Lines {
+			if sum, err = sum.Add(l.Amount); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if sum != q.Total {
+			t.Errorf("%s: lines add up to %v; total is %v", tc.name, sum, q.Total)
+		}
+	}
+}
+
+func TestPriceStayLabelsNights(t *testing.T) {
+	prop := models.Property{Price: models.NewMoney(2000000, "NGN"), Currency: "NGN", WeekendUplift: 10, BaseGuests: 2, ExtraGuestFee: models.NewMoney(500000, "NGN")}
+	rates := []models.PropertyRate{{Label: "Easter", StartDate: date("2025-03-07"), EndDate: date("2025-03-08"), NightlyPrice: models.NewMoney(2500000, "NGN"), Currency: "NGN"}}
+	q, err := priceStay(&prop, rates, date("2025-03-06"), date("2025-03-08"), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []NightPrice{
+		{Date: "2025-03-06", Price: models.NewMoney(2500000, "NGN"), Rate: "base", ExtraGuests: 1},
+		{Date: "2025-03-07", Price: models.NewMoney(3250000, "NGN"), Rate: "Easter", Weekend: true, ExtraGuests: 1},
+	}
+	for i, n := range q.NightlyPrices {
+		if n != want[i] {
+			t.Errorf("night %d = %+v; want %+v", i, n, want[i])
+		}
+	}
+	if got := q.Lines[1].Description; got != "Night of 2025-03-07 (Easter) (weekend) (+1 guests)" {
+		t.Errorf("description = %q", got)
+	}
+}
+
+func TestPriceStayRejectsRatesInAnotherCurrency(t *testing.T) {
+	// the property moved to USD after an NGN rate was set
+	prop := models.Property{Price: models.NewMoney(15000, "USD"), Currency: "USD"}
+	rates := []models.PropertyRate{{Label: "Festive", StartDate: date("2025-12-20"), EndDate: date("2026-01-02"), NightlyPrice: models.NewMoney(5000000, "NGN"), Currency: "NGN"}}
+	if _, err := priceStay(&prop, rates, date("2025-12-24"), date("2025-12-27"), 1); !errors.Is(err, ErrRateCurrency) {
+		t.Errorf("err = %v; want ErrRateCurrency", err)
+	}
+}
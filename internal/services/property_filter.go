@@ -0,0 +1,399 @@
+package services
+
+import (
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+	"github.com/olamideolayemi/realestate-backend/internal/utils"
+)
+
+// PropertyCategories are the kinds of listing.
+var PropertyCategories = map[string]bool{"buy": true, "rent": true, "shortlet": true}
+
+// propertySorts maps the sort parameter to its columns. A leading "-"
+// reverses price and bedrooms; every order ends on id so pages are stable.
+var propertySorts = map[string][]utils.SortColumn{
+	"newest":    {{Column: "properties.created_at", Desc: true}, {Column: "properties.id", Desc: true}},
+	"price":     {{Column: "properties.price_minor"}, {Column: "properties.id"}},
+	"-price":    {{Column: "properties.price_minor", Desc: true}, {Column: "properties.id", Desc: true}},
+	"bedrooms":  {{Column: "properties.bedrooms"}, {Column: "properties.id"}},
+	"-bedrooms": {{Column: "properties.bedrooms", Desc: true}, {Column: "properties.id", Desc: true}},
+}
+
+// SortRelevance orders a keyword search best match first. It's the default
+// when q is given and isn't allowed without it.
+const SortRelevance = "relevance"
+
+// SortDistance orders by distance from lat/lng, which it requires.
+const SortDistance = "distance"
+
+// FilterError reports every query parameter that failed to parse, keyed by
+// parameter name.
+type FilterError struct {
+	Fields map[string]string
+}
+
+func (e *FilterError) Error() string {
+	names := make([]string, 0, len(e.Fields))
+	for name := range e.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + ": " + e.Fields[name]
+	}
+	return "invalid query parameters: " + strings.Join(parts, "; ")
+}
+
+// IntRange is an inclusive range; nil ends are open.
+type IntRange struct {
+	Min, Max *int
+}
+
+// PropertyFilter is the parsed query string of a property listing.
+type PropertyFilter struct {
+	Categories           []string
+	Areas                []string
+	CancellationPolicies []string
+	// Currency limits results to listings priced in it. MinPrice and MaxPrice
+	// are in its minor units, NGN when no currency is given.
+	Currency           string
+	MinPrice, MaxPrice *int64
+	Bedrooms           IntRange
+	Bathrooms          IntRange
+	Furnished          *bool
+	PartyAllowed       *bool
+	InstantBook        *bool
+	// CheckIn and CheckOut, given together, keep properties free for the stay.
+	CheckIn, CheckOut *time.Time
+	// BBox is a map viewport; Near with RadiusKm > 0 is a circle around a
+	// point. Near alone only serves the distance sort.
+	BBox     *BoundingBox
+	Near     *GeoPoint
+	RadiusKm float64
+	Search   *PropertySearch
+	Sort     string
+}
+
+// filterParser collects errors while reading url.Values.
+type filterParser struct {
+	q      url.Values
+	errors map[string]string
+}
+
+func (p *filterParser) fail(name, msg string) {
+	if _, ok := p.errors[name]; !ok {
+		p.errors[name] = msg
+	}
+}
+
+// list reads a comma-separated parameter, which may also be repeated.
+func (p *filterParser) list(name string, valid func(string) bool) []string {
+	var out []string
+	for _, v := range p.q[name] {
+		for _, item := range strings.Split(v, ",") {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+			if valid != nil && !valid(item) {
+				p.fail(name, "unknown value "+strconv.Quote(item))
+				continue
+			}
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func (p *filterParser) int(name string) *int {
+	v := strings.TrimSpace(p.q.Get(name))
+	if v == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		p.fail(name, "must be a whole number of 0 or more")
+		return nil
+	}
+	return &n
+}
+
+func (p *filterParser) intRange(min, max string) IntRange {
+	r := IntRange{Min: p.int(min), Max: p.int(max)}
+	if r.Min != nil && r.Max != nil && *r.Min > *r.Max {
+		p.fail(max, "must not be less than "+min)
+	}
+	return r
+}
+
+func (p *filterParser) bool(name string) *bool {
+	v := strings.TrimSpace(p.q.Get(name))
+	if v == "" {
+		return nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		p.fail(name, "must be true or false")
+		return nil
+	}
+	return &b
+}
+
+func (p *filterParser) price(name, currency string) *int64 {
+	v := strings.TrimSpace(p.q.Get(name))
+	if v == "" {
+		return nil
+	}
+	m, err := models.ParseMoney(v, currency)
+	if err != nil || m.IsNegative() {
+		p.fail(name, "must be an amount of 0 or more in "+currency)
+		return nil
+	}
+	return &m.Amount
+}
+
+// floats reads a comma-separated list of exactly n numbers.
+func (p *filterParser) floats(name string, n int) []float64 {
+	v := strings.TrimSpace(p.q.Get(name))
+	if v == "" {
+		return nil
+	}
+	parts := strings.Split(v, ",")
+	if len(parts) != n {
+		p.fail(name, "must be "+strconv.Itoa(n)+" comma-separated numbers")
+		return nil
+	}
+	out := make([]float64, n)
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			p.fail(name, "must be "+strconv.Itoa(n)+" comma-separated numbers")
+			return nil
+		}
+		out[i] = f
+	}
+	return out
+}
+
+func (p *filterParser) float(name string) *float64 {
+	if f := p.floats(name, 1); f != nil {
+		return &f[0]
+	}
+	return nil
+}
+
+func (p *filterParser) date(name string) *time.Time {
+	v := strings.TrimSpace(p.q.Get(name))
+	if v == "" {
+		return nil
+	}
+	d, err := ParseDate(v)
+	if err != nil {
+		p.fail(name, "must be a date in YYYY-MM-DD form")
+		return nil
+	}
+	return &d
+}
+
+// ParsePropertyFilter reads the listing query parameters, returning a
+// *FilterError naming every bad one.
+func ParsePropertyFilter(q url.Values) (*PropertyFilter, error) {
+	p := &filterParser{q: q, errors: map[string]string{}}
+	f := &PropertyFilter{
+		Categories:           p.list("category", func(v string) bool { return PropertyCategories[v] }),
+		Areas:                p.list("area", nil),
+		CancellationPolicies: p.list("cancellation_policy", ValidCancellationPolicy),
+		Bathrooms:            p.intRange("min_baths", "max_baths"),
+		Furnished:            p.bool("furnished"),
+		PartyAllowed:         p.bool("party_allowed"),
+		InstantBook:          p.bool("instant_book"),
+		CheckIn:              p.date("checkin"),
+		CheckOut:             p.date("checkout"),
+		Search:               NewPropertySearch(q.Get("q")),
+	}
+
+	// min_beds predates min_bedrooms and is still accepted
+	f.Bedrooms = p.intRange("min_bedrooms", "max_bedrooms")
+	if f.Bedrooms.Min == nil {
+		f.Bedrooms.Min = p.int("min_beds")
+	}
+
+	priceCurrency := "NGN"
+	if v := q.Get("currency"); v != "" {
+		cur, err := NormalizeCurrency(v)
+		if err != nil {
+			p.fail("currency", "must be a three-letter currency code")
+		} else {
+			f.Currency, priceCurrency = cur, cur
+		}
+	}
+	f.MinPrice = p.price("min_price", priceCurrency)
+	f.MaxPrice = p.price("max_price", priceCurrency)
+	if f.MinPrice != nil && f.MaxPrice != nil && *f.MinPrice > *f.MaxPrice {
+		p.fail("max_price", "must not be less than min_price")
+	}
+	if (f.MinPrice != nil || f.MaxPrice != nil) && f.Currency == "" {
+		f.Currency = priceCurrency
+	}
+
+	switch {
+	case (q.Get("checkin") == "") != (q.Get("checkout") == ""):
+		p.fail("checkout", "checkin and checkout must be given together")
+	case f.CheckIn != nil && f.CheckOut != nil && !f.CheckOut.After(*f.CheckIn):
+		p.fail("checkout", ErrInvalidRange.Error())
+	}
+
+	if b := p.floats("bbox", 4); b != nil {
+		box := BoundingBox{West: b[0], South: b[1], East: b[2], North: b[3]}
+		if box.Valid() {
+			f.BBox = &box
+		} else {
+			p.fail("bbox", "must be west,south,east,north in degrees with south <= north")
+		}
+	}
+	lat, lng := p.float("lat"), p.float("lng")
+	switch {
+	case (lat == nil) != (lng == nil):
+		p.fail("lng", "lat and lng must be given together")
+	case lat != nil:
+		if pt := (GeoPoint{Lat: *lat, Lng: *lng}); pt.Valid() {
+			f.Near = &pt
+		} else {
+			p.fail("lat", ErrInvalidCoordinates.Error())
+		}
+	}
+	if r := p.float("radius_km"); r != nil {
+		switch {
+		case *r <= 0 || *r > MaxRadiusKm:
+			p.fail("radius_km", "must be more than 0 and at most "+strconv.Itoa(MaxRadiusKm))
+		case lat == nil:
+			p.fail("radius_km", "needs lat and lng")
+		default:
+			f.RadiusKm = *r
+		}
+	}
+
+	f.Sort = strings.TrimSpace(q.Get("sort"))
+	switch {
+	case f.Sort == "" && f.Search != nil:
+		f.Sort = SortRelevance
+	case f.Sort == "":
+		f.Sort = "newest"
+	case f.Sort == SortRelevance:
+		if f.Search == nil {
+			p.fail("sort", "relevance needs a q search")
+		}
+	case f.Sort == SortDistance:
+		if lat == nil {
+			p.fail("sort", "distance needs lat and lng")
+		}
+	case propertySorts[f.Sort] == nil:
+		p.fail("sort", "must be one of newest, price, -price, bedrooms, -bedrooms, relevance, distance")
+	}
+
+	if len(p.errors) > 0 {
+		return nil, &FilterError{Fields: p.errors}
+	}
+	return f, nil
+}
+
+// Where applies every filter except the stay dates, which need the
+// AvailabilityService.
+func (f *PropertyFilter) Where(db *gorm.DB) *gorm.DB {
+	if len(f.Categories) > 0 {
+		db = db.Where("properties.category IN ?", f.Categories)
+	}
+	if len(f.Areas) > 0 {
+		lower := make([]string, len(f.Areas))
+		for i, a := range f.Areas {
+			lower[i] = strings.ToLower(a)
+		}
+		db = db.Where("lower(properties.area) IN ?", lower)
+	}
+	if len(f.CancellationPolicies) > 0 {
+		db = db.Where("properties.cancellation_policy IN ?", f.CancellationPolicies)
+	}
+	if f.Currency != "" {
+		db = db.Where("properties.currency = ?", f.Currency)
+	}
+	if f.MinPrice != nil {
+		db = db.Where("properties.price_minor >= ?", *f.MinPrice)
+	}
+	if f.MaxPrice != nil {
+		db = db.Where("properties.price_minor <= ?", *f.MaxPrice)
+	}
+	for _, r := range []struct {
+		column string
+		rng    IntRange
+	}{
+		{"properties.bedrooms", f.Bedrooms},
+		{"properties.bathrooms", f.Bathrooms},
+	} {
+		if r.rng.Min != nil {
+			db = db.Where(r.column+" >= ?", *r.rng.Min)
+		}
+		if r.rng.Max != nil {
+			db = db.Where(r.column+" <= ?", *r.rng.Max)
+		}
+	}
+	for _, b := range []struct {
+		column string
+		value  *bool
+	}{
+		{"properties.furnished", f.Furnished},
+		{"properties.party_allowed", f.PartyAllowed},
+		{"properties.instant_book", f.InstantBook},
+	} {
+		if b.value != nil {
+			db = db.Where(b.column+" = ?", *b.value)
+		}
+	}
+	if f.BBox != nil {
+		db = f.BBox.Where(db)
+	}
+	if f.Near != nil && f.RadiusKm > 0 {
+		db = WithinKm(*f.Near, f.RadiusKm)(db)
+	}
+	if f.Search != nil {
+		db = f.Search.Filter(db)
+	}
+	return db
+}
+
+// Order applies the relevance and distance sorts. The column sorts are
+// applied by a utils.Paginator given SortColumns.
+func (f *PropertyFilter) Order(db *gorm.DB) *gorm.DB {
+	switch f.Sort {
+	case SortRelevance:
+		return f.Search.ByRelevance(db)
+	case SortDistance:
+		return NearestFirst(*f.Near)(db)
+	}
+	return db
+}
+
+// SortColumns are the columns of a column sort, nil for the computed sorts
+// (relevance and distance), which can only be paged by page number.
+func (f *PropertyFilter) SortColumns() []utils.SortColumn {
+	return propertySorts[f.Sort]
+}
+
+// SortKey is p's values for SortColumns, for the next page's cursor.
+func (f *PropertyFilter) SortKey(p *models.Property) []interface{} {
+	switch f.Sort {
+	case "price", "-price":
+		return []interface{}{p.Price.Amount, p.ID}
+	case "bedrooms", "-bedrooms":
+		return []interface{}{p.Bedrooms, p.ID}
+	}
+	return []interface{}{p.CreatedAt, p.ID}
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestParsePropertyFilterRejects(t *testing.T) {
+	for _, tc := range []struct {
+		query  string
+		fields map[string]string
+	}{
+		{"category=castle", map[string]string{"category": `unknown value "castle"`}},
+		{"cancellation_policy=flexible,lenient", map[string]string{"cancellation_policy": `unknown value "lenient"`}},
+		{"min_bedrooms=-1", map[string]string{"min_bedrooms": "must be a whole number of 0 or more"}},
+		{"min_beds=two", map[string]string{"min_beds": "must be a whole number of 0 or more"}},
+		{"min_baths=3&max_baths=2", map[string]string{"max_baths": "must not be less than min_baths"}},
+		{"furnished=maybe", map[string]string{"furnished": "must be true or false"}},
+		{"currency=naira", map[string]string{"currency": "must be a three-letter currency code"}},
+		{"min_price=12.345", map[string]string{"min_price": "must be an amount of 0 or more in NGN"}},
+		{"currency=usd&max_price=-5", map[string]string{"max_price": "must be an amount of 0 or more in USD"}},
+		{"min_price=500&max_price=100", map[string]string{"max_price": "must not be less than min_price"}},
+		{"checkin=2026-05-01", map[string]string{"checkout": "checkin and checkout must be given together"}},
+		{"checkin=2026-05-03&checkout=2026-05-01", map[string]string{"checkout": ErrInvalidRange.Error()}},
+		{"checkin=01/05/2026&checkout=2026-05-03", map[string]string{"checkin": "must be a date in YYYY-MM-DD form"}},
+		{"bbox=3.3,6.4,3.5", map[string]string{"bbox": "must be 4 comma-separated numbers"}},
+		{"bbox=3.3,6.6,3.5,6.4", map[string]string{"bbox": "must be west,south,east,north in degrees with south <= north"}},
+		{"lat=6.5", map[string]string{"lng": "lat and lng must be given together"}},
+		{"lat=91&lng=3.4", map[string]string{"lat": ErrInvalidCoordinates.Error()}},
+		{"lat=NaN&lng=3.4", map[string]string{"lat": "must be 1 comma-separated numbers", "lng": "lat and lng must be given together"}},
+		{"lat=6.5&lng=3.4&radius_km=0", map[string]string{"radius_km": "must be more than 0 and at most 500"}},
+		{"radius_km=5", map[string]string{"radius_km": "needs lat and lng"}},
+		{"sort=relevance", map[string]string{"sort": "relevance needs a q search"}},
+		{"sort=distance", map[string]string{"sort": "distance needs lat and lng"}},
+		{"sort=cheapest", map[string]string{"sort": "must be one of newest, price, -price, bedrooms, -bedrooms, relevance, distance"}},
+		// every bad parameter is reported at once
+		{"category=castle&furnished=maybe&sort=cheapest", map[string]string{
+			"category":  `unknown value "castle"`,
+			"furnished": "must be true or false",
+			"sort":      "must be one of newest, price, -price, bedrooms, -bedrooms, relevance, distance",
+		}},
+	} {
+		q, err := url.ParseQuery(tc.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := ParsePropertyFilter(q)
+		var fe *FilterError
+		if !errors.As(err, &fe) {
+			t.Errorf("%s: got %+v, %v; want a FilterError", tc.query, f, err)
+			continue
+		}
+		if !reflect.DeepEqual(fe.Fields, tc.fields) {
+			t.Errorf("%s: fields = %v; want %v", tc.query, fe.Fields, tc.fields)
+		}
+	}
+}
+
+func TestParsePropertyFilter(t *testing.T) {
+	q, err := url.ParseQuery("category=rent,shortlet&area=Lekki&area=Ikoyi&currency=usd&min_price=100.50" +
+		"&min_beds=2&checkin=2026-05-01&checkout=2026-05-04&lat=6.45&lng=3.40&radius_km=10&sort=-price")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := ParsePropertyFilter(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(f.Categories, []string{"rent", "shortlet"}) || !reflect.DeepEqual(f.Areas, []string{"Lekki", "Ikoyi"}) {
+		t.Errorf("categories %v areas %v", f.Categories, f.Areas)
+	}
+	if f.Currency != "USD" || f.MinPrice == nil || *f.MinPrice != 10050 || f.MaxPrice != nil {
+		t.Errorf("currency %q min %v max %v; want USD, 10050 cents, none", f.Currency, f.MinPrice, f.MaxPrice)
+	}
+	if f.Bedrooms.Min == nil || *f.Bedrooms.Min != 2 {
+		t.Errorf("min_beds did not set Bedrooms.Min: %+v", f.Bedrooms)
+	}
+	if f.CheckIn == nil || !f.CheckIn.Equal(date("2026-05-01")) || f.CheckOut == nil || !f.CheckOut.Equal(date("2026-05-04")) {
+		t.Errorf("stay %v to %v", f.CheckIn, f.CheckOut)
+	}
+	if f.Near == nil || f.RadiusKm != 10 || f.Sort != "-price" {
+		t.Errorf("near %v radius %v sort %q", f.Near, f.RadiusKm, f.Sort)
+	}
+
+	// the sort defaults to relevance for a search and newest otherwise
+	for query, sort := range map[string]string{"q=pool": SortRelevance, "": "newest"} {
+		q, _ := url.ParseQuery(query)
+		if f, err := ParsePropertyFilter(q); err != nil || f.Sort != sort {
+			t.Errorf("%q: sort %v, %v; want %s", query, f, err, sort)
+		}
+	}
+}
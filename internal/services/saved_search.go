@@ -0,0 +1,335 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+	"github.com/olamideolayemi/realestate-backend/internal/utils"
+)
+
+const (
+	// MaxSavedSearches caps the saved searches of one user.
+	MaxSavedSearches = 20
+	// digestListings caps the listings shown in one alert email.
+	digestListings = 20
+	alertBatch     = 100
+)
+
+var (
+	ErrInvalidFrequency     = errors.New("frequency must be instant, daily or weekly")
+	ErrTooManySavedSearches = fmt.Errorf("at most %d saved searches per user", MaxSavedSearches)
+	// ErrStaleSearchQuery means a saved query no longer parses, say after a
+	// filter it uses was dropped. Retrying won't help.
+	ErrStaleSearchQuery = errors.New("the saved filters are no longer valid; edit the search to turn alerts back on")
+)
+
+// alertIntervals is how long each frequency waits between emails. Instant
+// searches are checked on every run.
+var alertIntervals = []struct {
+	frequency string
+	every     time.Duration
+}{
+	{models.AlertInstant, 0},
+	{models.AlertDaily, 24 * time.Hour},
+	{models.AlertWeekly, 7 * 24 * time.Hour},
+}
+
+// searchOnlyParams are ListProperties parameters that don't change which
+// listings match, so they aren't kept in a saved search.
+var searchOnlyParams = []string{"page", "cursor", "limit", "sort", "display_currency"}
+
+// SavedSearchService stores users' saved searches and emails them listings
+// that newly match.
+type SavedSearchService struct {
+	DB           *gorm.DB
+	Availability *AvailabilityService
+	// BaseURL is the public URL of the API, for links in emails.
+	BaseURL string
+	// Interval is the time between matcher runs in the background.
+	Interval time.Duration
+	// Now and SendMail default to time.Now and utils.SendMail.
+	Now      func() time.Time
+	SendMail func(to, subject, body string) error
+}
+
+func (s *SavedSearchService) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+func (s *SavedSearchService) sendMail(to, subject, body string) error {
+	if s.SendMail != nil {
+		return s.SendMail(to, subject, body)
+	}
+	return utils.SendMail(to, subject, body)
+}
+
+// NormalizeSearchQuery checks a ListProperties query string and returns it
+// in canonical form, without paging or display parameters.
+func NormalizeSearchQuery(raw string) (string, error) {
+	values, err := url.ParseQuery(strings.TrimPrefix(strings.TrimSpace(raw), "?"))
+	if err != nil {
+		return "", &FilterError{Fields: map[string]string{"query": "is not a valid query string"}}
+	}
+	for _, k := range searchOnlyParams {
+		values.Del(k)
+	}
+	if _, err := ParsePropertyFilter(values); err != nil {
+		return "", err
+	}
+	return values.Encode(), nil
+}
+
+// Create saves a search for userID. Only listings published from now on
+// are alerted.
+func (s *SavedSearchService) Create(ctx context.Context, userID uuid.UUID, name, query, frequency string) (*models.SavedSearch, error) {
+	if frequency == "" {
+		frequency = models.AlertDaily
+	}
+	if !models.ValidAlertFrequency(frequency) {
+		return nil, ErrInvalidFrequency
+	}
+	query, err := NormalizeSearchQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	token, err := newUnsubscribeToken()
+	if err != nil {
+		return nil, err
+	}
+	search := models.SavedSearch{
+		UserID:           userID,
+		Name:             name,
+		Query:            query,
+		Frequency:        frequency,
+		Alerts:           true,
+		UnsubscribeToken: token,
+		LastCheckedAt:    s.now(),
+	}
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// lock the user so concurrent saves can't pass the cap together
+		var u models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&u, "id = ?", userID).Error; err != nil {
+			return err
+		}
+		var n int64
+		if err := tx.Model(&models.SavedSearch{}).Where("user_id = ?", userID).Count(&n).Error; err != nil {
+			return err
+		}
+		if n >= MaxSavedSearches {
+			return ErrTooManySavedSearches
+		}
+		return tx.Create(&search).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &search, nil
+}
+
+// Unsubscribe turns off alerts for the search the token was mailed for.
+func (s *SavedSearchService) Unsubscribe(ctx context.Context, token string) (*models.SavedSearch, error) {
+	var search models.SavedSearch
+	if token == "" {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if err := s.DB.WithContext(ctx).First(&search, "unsubscribe_token = ?", token).Error; err != nil {
+		return nil, err
+	}
+	if err := s.DB.WithContext(ctx).Model(&search).Update("alerts", false).Error; err != nil {
+		return nil, err
+	}
+	return &search, nil
+}
+
+// Run matches every Interval until ctx is cancelled.
+func (s *SavedSearchService) Run(ctx context.Context) {
+	interval := s.Interval
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := s.Match(ctx); err != nil {
+			log.Println("saved search alerts failed:", err)
+		} else if n > 0 {
+			log.Printf("saved search alerts: sent %d emails", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Match emails the owner of every due saved search the listings published
+// since it was last checked. It returns the number of emails sent.
+func (s *SavedSearchService) Match(ctx context.Context) (int, error) {
+	now := s.now()
+	sent := 0
+	var failed []uuid.UUID
+	for {
+		if err := ctx.Err(); err != nil {
+			return sent, nil
+		}
+		var batch []models.SavedSearch
+		q := s.DB.WithContext(ctx).Where("alerts AND last_checked_at < ?", now)
+		var due []string
+		var dueArgs []interface{}
+		for _, a := range alertIntervals {
+			due = append(due, "(frequency = ? AND last_checked_at <= ?)")
+			dueArgs = append(dueArgs, a.frequency, now.Add(-a.every))
+		}
+		q = q.Where("("+strings.Join(due, " OR ")+")", dueArgs...)
+		if len(failed) > 0 {
+			q = q.Where("id NOT IN ?", failed)
+		}
+		if err := q.Order("last_checked_at, id").Limit(alertBatch).Find(&batch).Error; err != nil {
+			return sent, err
+		}
+		if len(batch) == 0 {
+			return sent, nil
+		}
+		for i := range batch {
+			ok, err := s.alert(ctx, &batch[i], now)
+			if errors.Is(err, ErrStaleSearchQuery) {
+				log.Printf("saved search %s: %v; turning its alerts off", batch[i].ID, err)
+				err = s.DB.WithContext(ctx).Model(&models.SavedSearch{}).Where("id = ?", batch[i].ID).
+					Updates(map[string]interface{}{"alerts": false, "alerts_off_reason": ErrStaleSearchQuery.Error()}).Error
+			}
+			if err != nil {
+				// leave it due and retry on the next run
+				log.Printf("saved search %s: %v", batch[i].ID, err)
+				failed = append(failed, batch[i].ID)
+				continue
+			}
+			if ok {
+				sent++
+			}
+		}
+		if len(batch) < alertBatch {
+			return sent, nil
+		}
+	}
+}
+
+// alert checks one search for listings published in (LastCheckedAt, now]
+// and mails them. Either way the search is then checked up to now.
+func (s *SavedSearchService) alert(ctx context.Context, search *models.SavedSearch, now time.Time) (bool, error) {
+	props, more, err := s.newMatches(ctx, search, now)
+	if err != nil {
+		return false, err
+	}
+	updates := map[string]interface{}{"last_checked_at": now}
+	if len(props) > 0 {
+		var user models.User
+		if err := s.DB.WithContext(ctx).First(&user, "id = ?", search.UserID).Error; err != nil {
+			return false, err
+		}
+		subject, body := s.digest(&user, search, props, more)
+		if err := s.sendMail(user.Email, subject, body); err != nil {
+			return false, err
+		}
+		updates["last_sent_at"] = now
+	}
+	err = s.DB.WithContext(ctx).Model(&models.SavedSearch{}).Where("id = ?", search.ID).Updates(updates).Error
+	return len(props) > 0, err
+}
+
+// newMatches finds published listings matching search that went live in
+// (LastCheckedAt, until], newest first, reporting whether more than
+// digestListings did.
+func (s *SavedSearchService) newMatches(ctx context.Context, search *models.SavedSearch, until time.Time) ([]models.Property, bool, error) {
+	values, err := url.ParseQuery(search.Query)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %v", ErrStaleSearchQuery, err)
+	}
+	filter, err := ParsePropertyFilter(values)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %v", ErrStaleSearchQuery, err)
+	}
+	q := s.DB.WithContext(ctx).Model(&models.Property{}).Scopes(Published, filter.Where).
+		Where("COALESCE(properties.published_at, properties.created_at) > ?", search.LastCheckedAt).
+		Where("COALESCE(properties.published_at, properties.created_at) <= ?", until)
+	if filter.CheckIn != nil && s.Availability != nil {
+		q = q.Scopes(s.Availability.AvailableBetween(*filter.CheckIn, *filter.CheckOut))
+	}
+	var props []models.Property
+	err = q.Order("COALESCE(properties.published_at, properties.created_at) DESC, properties.id").
+		Limit(digestListings + 1).Find(&props).Error
+	if err != nil {
+		return nil, false, err
+	}
+	if len(props) > digestListings {
+		return props[:digestListings], true, nil
+	}
+	return props, false, nil
+}
+
+// digest writes the alert email.
+func (s *SavedSearchService) digest(user *models.User, search *models.SavedSearch, props []models.Property, more bool) (string, string) {
+	base := strings.TrimSuffix(s.BaseURL, "/")
+	var b strings.Builder
+	fmt.Fprintf(&b, "<p>Hi %s,<br>New listings match your saved search <b>%s</b>:</p><ul>",
+		html.EscapeString(user.Name), html.EscapeString(search.Name))
+	for _, p := range props {
+		link := base + "/api/v1/properties/" + p.ID.String()
+		fmt.Fprintf(&b, `<li><a href="%s">%s</a> &middot; %s &middot; %d bed &middot; %s</li>`,
+			html.EscapeString(link), html.EscapeString(p.Title), html.EscapeString(p.Area), p.Bedrooms, p.Price)
+	}
+	b.WriteString("</ul>")
+	if more {
+		all := base + "/api/v1/properties?" + search.Query
+		fmt.Fprintf(&b, `<p><a href="%s">See all matching listings</a></p>`, html.EscapeString(all))
+	}
+	unsubscribe := base + "/api/v1/saved-searches/unsubscribe?token=" + url.QueryEscape(search.UnsubscribeToken)
+	fmt.Fprintf(&b, `<p style="font-size:12px">You get these emails %s. <a href="%s">Unsubscribe from this search</a>.</p>`,
+		frequencyPhrase(search.Frequency), html.EscapeString(unsubscribe))
+
+	var subject string
+	switch {
+	case more:
+		subject = fmt.Sprintf("%d+ new listings for %s", len(props), search.Name)
+	case len(props) == 1:
+		subject = "1 new listing for " + search.Name
+	default:
+		subject = fmt.Sprintf("%d new listings for %s", len(props), search.Name)
+	}
+	return subject, b.String()
+}
+
+func frequencyPhrase(f string) string {
+	switch f {
+	case models.AlertInstant:
+		return "as soon as listings go live"
+	case models.AlertWeekly:
+		return "weekly"
+	}
+	return "daily"
+}
+
+func newUnsubscribeToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+)
+
+// mailbox records the alerts a SavedSearchService sends.
+type mailbox struct {
+	subjects map[string][]string // by recipient
+}
+
+func (m *mailbox) send(to, subject, body string) error {
+	m.subjects[to] = append(m.subjects[to], subject)
+	return nil
+}
+
+// searchFixture is a SavedSearchService whose clock starts at *now, with
+// one user to save searches for.
+func searchFixture(t *testing.T, db *gorm.DB) (*SavedSearchService, *time.Time, *mailbox, *models.User) {
+	t.Helper()
+	u := models.User{Email: "hunter@example.com", PasswordHash: "x", Name: "Tolu", Role: models.RoleUser, IsVerified: true}
+	if err := db.Create(&u).Error; err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 6, 1, 8, 0, 0, 0, time.UTC)
+	box := &mailbox{subjects: map[string][]string{}}
+	s := &SavedSearchService{DB: db, Now: func() time.Time { return now }, SendMail: box.send}
+	return s, &now, box, &u
+}
+
+func publishListing(t *testing.T, db *gorm.DB, title, area string, at time.Time) {
+	t.Helper()
+	p := models.Property{Title: title, Area: area, Status: models.PropertyPublished, Currency: "NGN", PublishedAt: &at}
+	if err := db.Create(&p).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMatchAlertsDueSearches(t *testing.T) {
+	db := testDB(t)
+	s, now, box, u := searchFixture(t, db)
+	ctx := context.Background()
+	start := *now
+
+	searches := map[string]*models.SavedSearch{}
+	for _, freq := range []string{models.AlertInstant, models.AlertDaily, models.AlertWeekly} {
+		search, err := s.Create(ctx, u.ID, freq, "area=Lekki", freq)
+		if err != nil {
+			t.Fatal(err)
+		}
+		searches[freq] = search
+	}
+	publishListing(t, db, "Lekki studio", "Lekki", start.Add(time.Hour))
+	publishListing(t, db, "Ikoyi loft", "Ikoyi", start.Add(time.Hour))
+
+	checked := func(freq string) time.Time {
+		t.Helper()
+		var got models.SavedSearch
+		reload(t, db, &got, searches[freq].ID)
+		return got.LastCheckedAt
+	}
+	run := func(at time.Time, want ...string) {
+		t.Helper()
+		*now = at
+		box.subjects = map[string][]string{}
+		sent, err := s.Match(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := box.subjects[u.Email]
+		if sent != len(want) || len(got) != len(want) {
+			t.Fatalf("Match at %s sent %d: %v; want %v", at, sent, got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("Match at %s: subject %q; want %q", at, got[i], want[i])
+			}
+		}
+	}
+
+	// only instant searches are due two hours in
+	run(start.Add(2*time.Hour), "1 new listing for instant")
+	if c := checked(models.AlertInstant); !c.Equal(start.Add(2 * time.Hour)) {
+		t.Errorf("instant search checked up to %s; want %s", c, start.Add(2*time.Hour))
+	}
+	if c := checked(models.AlertDaily); !c.Equal(start) {
+		t.Errorf("daily search checked up to %s before it was due", c)
+	}
+
+	// a day in the daily search is due; the instant one has nothing new but
+	// is still checked up to now
+	run(start.Add(25*time.Hour), "1 new listing for daily")
+	if c := checked(models.AlertInstant); !c.Equal(start.Add(25 * time.Hour)) {
+		t.Errorf("instant search checked up to %s; want %s", c, start.Add(25*time.Hour))
+	}
+
+	run(start.Add(8*24*time.Hour), "1 new listing for weekly")
+	// and nothing is sent twice
+	run(start.Add(9 * 24 * time.Hour))
+}
+
+func TestUnsubscribeStopsAlerts(t *testing.T) {
+	db := testDB(t)
+	s, now, box, u := searchFixture(t, db)
+	ctx := context.Background()
+	search, err := s.Create(ctx, u.ID, "Lekki", "area=Lekki", models.AlertInstant)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Unsubscribe(ctx, "not-a-token"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("unknown token: err = %v; want not found", err)
+	}
+	got, err := s.Unsubscribe(ctx, search.UnsubscribeToken)
+	if err != nil || got.ID != search.ID {
+		t.Fatalf("Unsubscribe = %+v, %v", got, err)
+	}
+	publishListing(t, db, "Lekki studio", "Lekki", now.Add(time.Hour))
+	*now = now.Add(2 * time.Hour)
+	if sent, err := s.Match(ctx); err != nil || sent != 0 || len(box.subjects) != 0 {
+		t.Errorf("Match after unsubscribing sent %d (%v), %v", sent, box.subjects, err)
+	}
+}
+
+func TestMatchTurnsOffStaleSearches(t *testing.T) {
+	db := testDB(t)
+	s, now, box, u := searchFixture(t, db)
+	ctx := context.Background()
+	search, err := s.Create(ctx, u.ID, "Lekki", "area=Lekki", models.AlertInstant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// saved before the sort it names was dropped
+	if err := db.Model(search).Update("query", "area=Lekki&sort=cheapest").Error; err != nil {
+		t.Fatal(err)
+	}
+	publishListing(t, db, "Lekki studio", "Lekki", now.Add(time.Hour))
+
+	*now = now.Add(2 * time.Hour)
+	if sent, err := s.Match(ctx); err != nil || sent != 0 {
+		t.Fatalf("Match = %d, %v; want nothing sent", sent, err)
+	}
+	var got models.SavedSearch
+	reload(t, db, &got, search.ID)
+	if got.Alerts || got.AlertsOffReason != ErrStaleSearchQuery.Error() {
+		t.Errorf("stale search: alerts %v reason %q; want off with a reason", got.Alerts, got.AlertsOffReason)
+	}
+	if len(box.subjects) != 0 {
+		t.Errorf("stale search mailed %v", box.subjects)
+	}
+}
+
+func TestCreateSavedSearchRejectsBadQueries(t *testing.T) {
+	db := testDB(t)
+	s, _, _, u := searchFixture(t, db)
+	var fe *FilterError
+	if _, err := s.Create(context.Background(), u.ID, "Bad", "sort=cheapest", models.AlertDaily); !errors.As(err, &fe) {
+		t.Errorf("bad query: err = %v; want a FilterError", err)
+	}
+	if _, err := s.Create(context.Background(), u.ID, "Bad", "area=Lekki", "hourly"); !errors.Is(err, ErrInvalidFrequency) {
+		t.Errorf("bad frequency: err = %v; want ErrInvalidFrequency", err)
+	}
+	if _, err := s.Create(context.Background(), uuid.New(), "Nobody", "area=Lekki", models.AlertDaily); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("unknown user: err = %v; want not found", err)
+	}
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"html"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// MaxSearchLength caps the q parameter, in characters.
+	MaxSearchLength = 200
+	// areaSimilarity is the pg_trgm word similarity above which an area name
+	// counts as mentioned in the query, so "Lekky" still finds Lekki.
+	areaSimilarity = 0.5
+)
+
+// Highlight markers used inside Postgres and swapped for <mark> tags after
+// the rest of the text has been HTML-escaped.
+const (
+	markStart = "\x02"
+	markStop  = "\x03"
+)
+
+// Expressions over properties.search_vector (see migrations/004).
+const (
+	searchQuery = "websearch_to_tsquery('english', ?)"
+	searchMatch = "(properties.search_vector @@ " + searchQuery +
+		" OR word_similarity(lower(properties.area), lower(?)) >= ?)"
+	searchRank = "(ts_rank_cd(properties.search_vector, " + searchQuery + ")" +
+		" + 0.5 * word_similarity(lower(properties.area), lower(?)))"
+)
+
+// PropertySearch is a keyword search over title, description, address and
+// area, ranked by relevance.
+type PropertySearch struct {
+	Text string
+}
+
+// NewPropertySearch returns nil for an empty query.
+func NewPropertySearch(q string) *PropertySearch {
+	// Postgres rejects invalid UTF-8 and NUL bytes in text parameters
+	q = strings.ReplaceAll(strings.ToValidUTF8(q, ""), "\x00", "")
+	if r := []rune(q); len(r) > MaxSearchLength {
+		q = string(r[:MaxSearchLength])
+	}
+	q = strings.TrimSpace(q)
+	if q == "" {
+		return nil
+	}
+	return &PropertySearch{Text: q}
+}
+
+// Filter limits a property query to matches.
+func (s *PropertySearch) Filter(db *gorm.DB) *gorm.DB {
+	return db.Where(searchMatch, s.Text, s.Text, areaSimilarity)
+}
+
+// ByRelevance orders a property query best match first, newest first among
+// equals. It replaces any other ordering.
+func (s *PropertySearch) ByRelevance(db *gorm.DB) *gorm.DB {
+	return db.Clauses(clause.OrderBy{Expression: clause.Expr{
+		SQL:                searchRank + " DESC, properties.created_at DESC, properties.id",
+		Vars:               []interface{}{s.Text, s.Text},
+		WithoutParentheses: true,
+	}})
+}
+
+// SearchHit is how one property matched a search.
+type SearchHit struct {
+	Rank float64 `json:"rank"`
+	// Title and Snippet are HTML-escaped with matches wrapped in <mark>.
+	Title   string `json:"title"`
+	Snippet string `json:"snippet"`
+}
+
+// Hits ranks and highlights the given properties against the search.
+func (s *PropertySearch) Hits(db *gorm.DB, ids []uuid.UUID) (map[uuid.UUID]SearchHit, error) {
+	hits := make(map[uuid.UUID]SearchHit, len(ids))
+	if len(ids) == 0 {
+		return hits, nil
+	}
+	opts := "StartSel=" + markStart + ", StopSel=" + markStop
+	var rows []struct {
+		ID      uuid.UUID
+		Rank    float64
+		Title   string
+		Snippet string
+	}
+	err := db.Table("properties").
+		Select("properties.id, "+searchRank+" AS rank, "+
+			"ts_headline('english', coalesce(properties.title, ''), "+searchQuery+", ?) AS title, "+
+			"ts_headline('english', coalesce(properties.description, ''), "+searchQuery+", ?) AS snippet",
+			s.Text, s.Text,
+			s.Text, opts+", HighlightAll=true",
+			s.Text, opts+", MaxFragments=2, MaxWords=30, MinWords=10, FragmentDelimiter=\" … \"").
+		Where("properties.id IN ?", ids).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		hits[r.ID] = SearchHit{Rank: r.Rank, Title: highlight(r.Title), Snippet: highlight(r.Snippet)}
+	}
+	return hits, nil
+}
+
+// highlight escapes text for HTML and turns the markers into <mark> tags.
+func highlight(s string) string {
+	s = html.EscapeString(s)
+	s = strings.ReplaceAll(s, markStart, "<mark>")
+	return strings.ReplaceAll(s, markStop, "</mark>")
+}
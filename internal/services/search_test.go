@@ -0,0 +1,22 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestNewPropertySearchTruncatesOnCharacters(t *testing.T) {
+	// "é" is two bytes, so a byte cut at MaxSearchLength would split one
+	q := "a" + strings.Repeat("é", MaxSearchLength)
+	s := NewPropertySearch(q)
+	if s == nil || !utf8.ValidString(s.Text) || utf8.RuneCountInString(s.Text) != MaxSearchLength {
+		t.Fatalf("NewPropertySearch kept %+v; want %d valid characters", s, MaxSearchLength)
+	}
+	if s := NewPropertySearch("lek\xffki\x00"); s == nil || s.Text != "lekki" {
+		t.Errorf("invalid bytes kept: %+v", s)
+	}
+	if s := NewPropertySearch("   "); s != nil {
+		t.Errorf("blank query gave %+v; want nil", s)
+	}
+}
@@ -0,0 +1,270 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+	"github.com/olamideolayemi/realestate-backend/internal/utils"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected; all sessions of this login were revoked")
+	ErrSessionRevoked      = errors.New("session has been revoked")
+)
+
+const (
+	DefaultAccessTokenTTL  = 15 * time.Minute
+	DefaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// Session revocation reasons.
+const (
+	RevokeLogout        = "logout"
+	RevokeLogoutAll     = "logout_all"
+	RevokeReuseDetected = "reuse_detected"
+	RevokePasswordReset = "password_reset"
+)
+
+// SessionService issues short-lived access tokens and rotating refresh
+// tokens. Only a SHA-256 of each refresh token is stored.
+type SessionService struct {
+	DB         *gorm.DB
+	Secret     string
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+	// Now defaults to time.Now.
+	Now func() time.Time
+}
+
+// NewSessionServiceFromEnv reads JWT_SECRET, ACCESS_TOKEN_TTL_MINUTES and
+// REFRESH_TOKEN_TTL_DAYS.
+func NewSessionServiceFromEnv(db *gorm.DB) *SessionService {
+	s := &SessionService{
+		DB:         db,
+		Secret:     os.Getenv("JWT_SECRET"),
+		AccessTTL:  DefaultAccessTokenTTL,
+		RefreshTTL: DefaultRefreshTokenTTL,
+	}
+	if s.Secret == "" {
+		s.Secret = "dev_secret"
+	}
+	if v, err := strconv.Atoi(os.Getenv("ACCESS_TOKEN_TTL_MINUTES")); err == nil && v > 0 {
+		s.AccessTTL = time.Duration(v) * time.Minute
+	}
+	if v, err := strconv.Atoi(os.Getenv("REFRESH_TOKEN_TTL_DAYS")); err == nil && v > 0 {
+		s.RefreshTTL = time.Duration(v) * 24 * time.Hour
+	}
+	return s
+}
+
+func (s *SessionService) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+// ClientInfo describes the device a session was opened from.
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+// TokenPair is what a client receives on login and refresh.
+type TokenPair struct {
+	AccessToken  string    `json:"token"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresIn    int       `json:"expires_in"` // access token lifetime in seconds
+	SessionID    uuid.UUID `json:"session_id"`
+}
+
+// Issue opens a new session family for u, e.g. on login.
+func (s *SessionService) Issue(ctx context.Context, u *models.User, client ClientInfo) (*TokenPair, error) {
+	var pair *TokenPair
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		pair, err = s.issue(tx, u, uuid.New(), client)
+		return err
+	})
+	return pair, err
+}
+
+func (s *SessionService) issue(tx *gorm.DB, u *models.User, family uuid.UUID, client ClientInfo) (*TokenPair, error) {
+	refresh, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	sess := models.Session{
+		ID:        uuid.New(),
+		UserID:    u.ID,
+		FamilyID:  family,
+		TokenHash: hashToken(refresh),
+		ExpiresAt: now.Add(s.RefreshTTL),
+		UserAgent: client.UserAgent,
+		IP:        client.IP,
+		CreatedAt: now,
+	}
+	if err := tx.Create(&sess).Error; err != nil {
+		return nil, err
+	}
+	access, err := utils.GenerateAccessToken(u.ID.String(), u.Role, family.String(), s.Secret, s.AccessTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		ExpiresIn:    int(s.AccessTTL.Seconds()),
+		SessionID:    family,
+	}, nil
+}
+
+// Refresh exchanges a refresh token for a new pair, retiring the old token.
+// Presenting a token that was already exchanged means it leaked, so the
+// whole family is revoked and ErrRefreshTokenReused returned.
+func (s *SessionService) Refresh(ctx context.Context, refreshToken string, client ClientInfo) (*TokenPair, error) {
+	var pair *TokenPair
+	reused := false
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var sess models.Session
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&sess, "token_hash = ?", hashToken(refreshToken)).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidRefreshToken
+			}
+			return err
+		}
+		now := s.now()
+		switch {
+		case sess.RevokedAt != nil:
+			return ErrInvalidRefreshToken
+		case sess.RotatedAt != nil:
+			reused = true
+			log.Printf("refresh token reuse detected for user %s, session %s; revoking", sess.UserID, sess.FamilyID)
+			return s.revokeFamily(tx, sess.FamilyID, RevokeReuseDetected)
+		case !now.Before(sess.ExpiresAt):
+			return ErrInvalidRefreshToken
+		}
+
+		var u models.User
+		if err := tx.First(&u, "id = ?", sess.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidRefreshToken
+			}
+			return err
+		}
+		if err := tx.Model(&sess).Update("rotated_at", now).Error; err != nil {
+			return err
+		}
+		if client.UserAgent == "" {
+			client.UserAgent = sess.UserAgent
+		}
+		var err error
+		pair, err = s.issue(tx, &u, sess.FamilyID, client)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reused {
+		// the revocation above has committed; now report the reuse
+		return nil, ErrRefreshTokenReused
+	}
+	return pair, nil
+}
+
+// Revoke logs one session family out.
+func (s *SessionService) Revoke(ctx context.Context, userID, family uuid.UUID, reason string) error {
+	res := s.DB.WithContext(ctx).Model(&models.Session{}).
+		Where("user_id = ? AND family_id = ? AND revoked_at IS NULL", userID, family).
+		Updates(map[string]interface{}{"revoked_at": s.now(), "revoked_reason": reason})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// RevokeAll logs every device of a user out and returns how many sessions
+// were ended.
+func (s *SessionService) RevokeAll(ctx context.Context, userID uuid.UUID, reason string) (int64, error) {
+	var families int64
+	if err := s.DB.WithContext(ctx).Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL AND rotated_at IS NULL AND expires_at > ?", userID, s.now()).
+		Count(&families).Error; err != nil {
+		return 0, err
+	}
+	return families, s.revokeUser(s.DB.WithContext(ctx), userID, reason)
+}
+
+func (s *SessionService) revokeUser(tx *gorm.DB, userID uuid.UUID, reason string) error {
+	return tx.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Updates(map[string]interface{}{"revoked_at": s.now(), "revoked_reason": reason}).Error
+}
+
+func (s *SessionService) revokeFamily(tx *gorm.DB, family uuid.UUID, reason string) error {
+	return tx.Model(&models.Session{}).
+		Where("family_id = ? AND revoked_at IS NULL", family).
+		Updates(map[string]interface{}{"revoked_at": s.now(), "revoked_reason": reason}).Error
+}
+
+// Active lists a user's signed-in devices: the current token of each live
+// family.
+func (s *SessionService) Active(ctx context.Context, userID uuid.UUID) ([]models.Session, error) {
+	var sessions []models.Session
+	err := s.DB.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND rotated_at IS NULL AND expires_at > ?", userID, s.now()).
+		Order("created_at DESC").Find(&sessions).Error
+	return sessions, err
+}
+
+// ValidateSession reports whether an access token's session family is still
+// live. Tokens issued before sessions existed carry no family and are
+// rejected.
+func ValidateSession(db *gorm.DB, userID uuid.UUID, family string) error {
+	id, err := uuid.Parse(family)
+	if err != nil {
+		return ErrSessionRevoked
+	}
+	var n int64
+	if err := db.Model(&models.Session{}).
+		Where("family_id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Count(&n).Error; err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrSessionRevoked
+	}
+	return nil
+}
+
+func newRefreshToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+)
+
+// sessionFixture is a signed-in user and a SessionService whose clock the
+// test controls.
+func sessionFixture(t *testing.T, db *gorm.DB) (*SessionService, *time.Time, *models.User, *TokenPair) {
+	t.Helper()
+	u := models.User{Email: "guest@example.com", PasswordHash: "x", Name: "Ada", Role: models.RoleUser, IsVerified: true}
+	if err := db.Create(&u).Error; err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	s := &SessionService{DB: db, Secret: "test", AccessTTL: DefaultAccessTokenTTL, RefreshTTL: DefaultRefreshTokenTTL,
+		Now: func() time.Time { return now }}
+	pair, err := s.Issue(context.Background(), &u, ClientInfo{UserAgent: "phone"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, &now, &u, pair
+}
+
+func TestRefreshRotatesToken(t *testing.T) {
+	db := testDB(t)
+	s, _, u, first := sessionFixture(t, db)
+
+	next, err := s.Refresh(context.Background(), first.RefreshToken, ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.RefreshToken == first.RefreshToken || next.SessionID != first.SessionID {
+		t.Errorf("refresh = %+v; want a new token in family %s", next, first.SessionID)
+	}
+	active, err := s.Active(context.Background(), u.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(active) != 1 || active[0].UserAgent != "phone" {
+		t.Errorf("active sessions = %+v; want the rotated phone session only", active)
+	}
+	if err := ValidateSession(db, u.ID, first.SessionID.String()); err != nil {
+		t.Errorf("ValidateSession after rotation: %v", err)
+	}
+}
+
+func TestRefreshReuseRevokesFamily(t *testing.T) {
+	db := testDB(t)
+	s, _, u, first := sessionFixture(t, db)
+	ctx := context.Background()
+	other, err := s.Issue(ctx, u, ClientInfo{UserAgent: "laptop"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	next, err := s.Refresh(ctx, first.RefreshToken, ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the old token turns up again: it leaked
+	if _, err := s.Refresh(ctx, first.RefreshToken, ClientInfo{}); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("replayed refresh: err = %v; want ErrRefreshTokenReused", err)
+	}
+	if _, err := s.Refresh(ctx, next.RefreshToken, ClientInfo{}); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("refresh with the family's newest token: err = %v; want ErrInvalidRefreshToken", err)
+	}
+	if err := ValidateSession(db, u.ID, first.SessionID.String()); !errors.Is(err, ErrSessionRevoked) {
+		t.Errorf("ValidateSession of the reused family: err = %v; want ErrSessionRevoked", err)
+	}
+	var revoked []models.Session
+	if err := db.Where("family_id = ?", first.SessionID).Find(&revoked).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, sess := range revoked {
+		if sess.RevokedAt == nil || sess.RevokedReason != RevokeReuseDetected {
+			t.Errorf("session %s: revoked %v reason %q; want reuse_detected", sess.ID, sess.RevokedAt, sess.RevokedReason)
+		}
+	}
+
+	// the user's other device is untouched
+	if err := ValidateSession(db, u.ID, other.SessionID.String()); err != nil {
+		t.Errorf("ValidateSession of another device: %v", err)
+	}
+	if _, err := s.Refresh(ctx, other.RefreshToken, ClientInfo{}); err != nil {
+		t.Errorf("refresh on another device: %v", err)
+	}
+}
+
+func TestRefreshRejectsExpiredAndUnknownTokens(t *testing.T) {
+	db := testDB(t)
+	s, now, _, pair := sessionFixture(t, db)
+
+	if _, err := s.Refresh(context.Background(), "not-a-token", ClientInfo{}); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("unknown token: err = %v; want ErrInvalidRefreshToken", err)
+	}
+	*now = now.Add(DefaultRefreshTokenTTL)
+	if _, err := s.Refresh(context.Background(), pair.RefreshToken, ClientInfo{}); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("expired token: err = %v; want ErrInvalidRefreshToken", err)
+	}
+}
@@ -1 +1,325 @@
 package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BlobStore keeps uploaded files under slash-separated keys such as
+// "properties/<id>/<file>.jpg".
+type BlobStore interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Delete removes key. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+	// URL is where clients can fetch key.
+	URL(key string) string
+}
+
+var ErrInvalidBlobKey = errors.New("invalid blob key")
+
+// NewBlobStoreFromEnv returns an S3-compatible store when S3_ENDPOINT is set
+// (with S3_BUCKET, S3_ACCESS_KEY, S3_SECRET_KEY and optionally S3_REGION and
+// S3_PUBLIC_URL; the bucket must exist), and a local store under UPLOAD_DIR
+// (default ./uploads) otherwise.
+func NewBlobStoreFromEnv() BlobStore {
+	if endpoint := os.Getenv("S3_ENDPOINT"); endpoint != "" {
+		return &S3BlobStore{
+			Endpoint:  strings.TrimRight(endpoint, "/"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			PublicURL: strings.TrimRight(os.Getenv("S3_PUBLIC_URL"), "/"),
+		}
+	}
+	dir := os.Getenv("UPLOAD_DIR")
+	if dir == "" {
+		dir = "uploads"
+	}
+	log.Println("S3_ENDPOINT not set; storing uploads in", dir)
+	return &LocalBlobStore{Dir: dir, URLPrefix: "/uploads"}
+}
+
+// checkKey rejects keys that could escape the store's root.
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return ErrInvalidBlobKey
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return ErrInvalidBlobKey
+		}
+	}
+	return nil
+}
+
+// LocalBlobStore keeps blobs on disk under Dir. The API serves Dir at
+// URLPrefix.
+type LocalBlobStore struct {
+	Dir       string
+	URLPrefix string
+}
+
+func (s *LocalBlobStore) path(key string) (string, error) {
+	if err := checkKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.Dir, filepath.FromSlash(key)), nil
+}
+
+func (s *LocalBlobStore) Put(_ context.Context, key string, data []byte, _ string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	// write then rename so readers never see a half-written file
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+func (s *LocalBlobStore) Delete(_ context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalBlobStore) URL(key string) string {
+	return strings.TrimRight(s.URLPrefix, "/") + "/" + key
+}
+
+// S3BlobStore talks to S3 or an S3-compatible server such as MinIO using
+// path-style URLs and Signature Version 4.
+type S3BlobStore struct {
+	Endpoint  string // e.g. http://minio:9000
+	Region    string // defaults to us-east-1
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// PublicURL is the base clients fetch objects from, e.g. a CDN.
+	// Defaults to Endpoint/Bucket.
+	PublicURL string
+	// Client defaults to a client with a 30s timeout.
+	Client *http.Client
+	// Now defaults to time.Now.
+	Now func() time.Time
+}
+
+var defaultS3Client = &http.Client{Timeout: 30 * time.Second}
+
+func (s *S3BlobStore) client() *http.Client {
+	if s.Client != nil {
+		return s.Client
+	}
+	return defaultS3Client
+}
+
+func (s *S3BlobStore) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+func (s *S3BlobStore) region() string {
+	if s.Region != "" {
+		return s.Region
+	}
+	return "us-east-1"
+}
+
+func (s *S3BlobStore) objectURL(key string) string {
+	return s.Endpoint + "/" + s3Escape(s.Bucket) + "/" + s3Escape(key)
+}
+
+func (s *S3BlobStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = int64(len(data))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return s.send(req, data, http.StatusOK)
+}
+
+func (s *S3BlobStore) Delete(ctx context.Context, key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key), nil)
+	if err != nil {
+		return err
+	}
+	return s.send(req, nil, http.StatusNoContent, http.StatusOK, http.StatusNotFound)
+}
+
+func (s *S3BlobStore) URL(key string) string {
+	if s.PublicURL != "" {
+		return s.PublicURL + "/" + s3Escape(key)
+	}
+	return s.objectURL(key)
+}
+
+func (s *S3BlobStore) send(req *http.Request, body []byte, ok ...int) error {
+	signV4(req, body, s.AccessKey, s.SecretKey, s.region(), "s3", s.now())
+	resp, err := s.client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	for _, code := range ok {
+		if resp.StatusCode == code {
+			io.Copy(io.Discard, resp.Body)
+			return nil
+		}
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("s3 %s %s: %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(msg)))
+}
+
+// s3Escape percent-encodes a key for a URL path, leaving slashes, as S3
+// expects in canonical requests.
+func s3Escape(key string) string {
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// signV4 adds AWS Signature Version 4 headers to req, signing the host,
+// every header already set on req and the payload hash.
+func signV4(req *http.Request, body []byte, accessKey, secretKey, region, service string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		if strings.EqualFold(name, "Authorization") {
+			continue
+		}
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3Escape(req.URL.Path),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), day)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+// canonicalQuery sorts and encodes query parameters for signing.
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		vals := append([]string{}, q[k]...)
+		sort.Strings(vals)
+		for _, v := range vals {
+			parts = append(parts, queryEscape(k)+"="+queryEscape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func queryEscape(s string) string {
+	return strings.ReplaceAll(s3Escape(s), "/", "%2F")
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"os"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/olamideolayemi/realestate-backend/internal/models"
+)
+
+var (
+	migrateOnce sync.Once
+	migrateErr  error
+)
+
+// testDB returns a transaction on the Postgres database in
+// TEST_DATABASE_URL, rolled back when the test ends. Tests that need it are
+// skipped when the variable is unset.
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	migrateOnce.Do(func() {
+		if migrateErr = db.Exec(`CREATE EXTENSION IF NOT EXISTS "uuid-ossp"`).Error; migrateErr != nil {
+			return
+		}
+		migrateErr = db.AutoMigrate(&models.User{}, &models.Property{}, &models.Booking{}, &models.PropertyBlock{},
+			&models.Payment{}, &models.BookingLineItem{}, &models.Session{}, &models.EmailVerification{},
+			&models.PropertyRate{}, &models.PropertyImage{}, &models.UserFavorite{}, &models.Wishlist{}, &models.WishlistItem{},
+			&models.SavedSearch{})
+	})
+	if migrateErr != nil {
+		t.Fatalf("migrate test database: %v", migrateErr)
+	}
+	tx := db.Begin()
+	if tx.Error != nil {
+		t.Fatalf("begin: %v", tx.Error)
+	}
+	t.Cleanup(func() { tx.Rollback() })
+	return tx
+}
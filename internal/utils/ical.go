@@ -0,0 +1,305 @@
+package utils
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ICalEvent is the subset of an RFC 5545 VEVENT needed to exchange
+// availability: an all-day range from Start up to (not including) End.
+type ICalEvent struct {
+	UID       string
+	Summary   string
+	Start     time.Time
+	End       time.Time
+	Stamp     time.Time
+	Cancelled bool
+}
+
+const (
+	icalDate     = "20060102"
+	icalDateTime = "20060102T150405"
+	icalLineMax  = 75
+)
+
+var ErrInvalidICal = errors.New("invalid iCalendar data")
+
+// WriteICal writes events as an RFC 5545 VCALENDAR with all-day VEVENTs.
+func WriteICal(w io.Writer, prodID, name string, events []ICalEvent) error {
+	bw := bufio.NewWriter(w)
+	line := func(s string) { writeFolded(bw, s) }
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:" + prodID)
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	if name != "" {
+		line("X-WR-CALNAME:" + escapeICalText(name))
+	}
+	for _, e := range events {
+		stamp := e.Stamp
+		if stamp.IsZero() {
+			stamp = time.Now()
+		}
+		line("BEGIN:VEVENT")
+		line("UID:" + escapeICalText(e.UID))
+		line("DTSTAMP:" + stamp.UTC().Format(icalDateTime) + "Z")
+		line("DTSTART;VALUE=DATE:" + e.Start.Format(icalDate))
+		line("DTEND;VALUE=DATE:" + e.End.Format(icalDate))
+		line("SUMMARY:" + escapeICalText(e.Summary))
+		line("TRANSP:OPAQUE")
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	return bw.Flush()
+}
+
+// writeFolded writes a content line, folding it at 75 octets without
+// splitting UTF-8 sequences.
+func writeFolded(w *bufio.Writer, s string) {
+	limit := icalLineMax
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8Start(s[cut]) {
+			cut--
+		}
+		w.WriteString(s[:cut])
+		w.WriteString("\r\n ")
+		s = s[cut:]
+		// continuation lines lose one octet to the leading space
+		limit = icalLineMax - 1
+	}
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
+
+func utf8Start(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+func escapeICalText(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return r.Replace(s)
+}
+
+func unescapeICalText(s string) string {
+	r := strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+	return r.Replace(s)
+}
+
+// ParseICal reads the VEVENTs of an RFC 5545 stream. Timed events are reduced
+// to the calendar days they touch; an event without DTEND lasts one day
+// unless it has a DURATION.
+func ParseICal(r io.Reader) ([]ICalEvent, error) {
+	lines, err := unfoldICal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		events   []ICalEvent
+		cur      *ICalEvent
+		duration time.Duration
+		sawCal   bool
+		depth    int // nesting inside the current VEVENT (e.g. VALARM)
+	)
+	for _, l := range lines {
+		name, params, value, ok := splitICalLine(l)
+		if !ok {
+			continue
+		}
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VCALENDAR"):
+			sawCal = true
+			continue
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			cur = &ICalEvent{}
+			duration = 0
+			depth = 0
+			continue
+		case cur == nil:
+			continue
+		case name == "BEGIN":
+			depth++
+			continue
+		case name == "END" && depth > 0:
+			depth--
+			continue
+		case depth > 0:
+			continue
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			if cur.Start.IsZero() {
+				return nil, fmt.Errorf("%w: VEVENT %q has no DTSTART", ErrInvalidICal, cur.UID)
+			}
+			if cur.End.IsZero() && duration > 0 {
+				cur.End = dateOf(cur.Start.Add(duration).Add(-time.Nanosecond)).AddDate(0, 0, 1)
+			}
+			if !cur.End.After(cur.Start) {
+				cur.End = cur.Start.AddDate(0, 0, 1)
+			}
+			events = append(events, *cur)
+			cur = nil
+			continue
+		}
+
+		switch name {
+		case "UID":
+			cur.UID = value
+		case "SUMMARY":
+			cur.Summary = unescapeICalText(value)
+		case "STATUS":
+			cur.Cancelled = strings.EqualFold(value, "CANCELLED")
+		case "DTSTAMP":
+			if t, err := parseICalTime(value, params); err == nil {
+				cur.Stamp = t
+			}
+		case "DTSTART":
+			t, err := parseICalTime(value, params)
+			if err != nil {
+				return nil, fmt.Errorf("%w: DTSTART %q", ErrInvalidICal, value)
+			}
+			cur.Start = dateOf(t)
+		case "DTEND":
+			t, err := parseICalTime(value, params)
+			if err != nil {
+				return nil, fmt.Errorf("%w: DTEND %q", ErrInvalidICal, value)
+			}
+			cur.End = dateOf(t)
+		case "DURATION":
+			d, err := parseICalDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: DURATION %q", ErrInvalidICal, value)
+			}
+			duration = d
+		}
+	}
+	if !sawCal {
+		return nil, fmt.Errorf("%w: missing BEGIN:VCALENDAR", ErrInvalidICal)
+	}
+	if cur != nil {
+		return nil, fmt.Errorf("%w: unterminated VEVENT", ErrInvalidICal)
+	}
+	return events, nil
+}
+
+// unfoldICal joins folded continuation lines (RFC 5545 section 3.1).
+func unfoldICal(r io.Reader) ([]string, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	var lines []string
+	for sc.Scan() {
+		l := strings.TrimRight(sc.Text(), "\r")
+		if len(l) > 0 && (l[0] == ' ' || l[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += l[1:]
+			continue
+		}
+		if l == "" {
+			continue
+		}
+		lines = append(lines, l)
+	}
+	return lines, sc.Err()
+}
+
+// splitICalLine splits `NAME;PARAM=V;...:VALUE`, ignoring colons inside
+// quoted parameter values.
+func splitICalLine(l string) (name string, params map[string]string, value string, ok bool) {
+	inQuote := false
+	colon := -1
+	for i := 0; i < len(l); i++ {
+		if l[i] == '"' {
+			inQuote = !inQuote
+		} else if l[i] == ':' && !inQuote {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return "", nil, "", false
+	}
+	head := strings.Split(l[:colon], ";")
+	params = make(map[string]string)
+	for _, p := range head[1:] {
+		if k, v, found := strings.Cut(p, "="); found {
+			params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+		}
+	}
+	return strings.ToUpper(head[0]), params, l[colon+1:], true
+}
+
+// parseICalTime parses a DATE or DATE-TIME value. Floating and TZID times
+// are read in that zone when it is known locally, otherwise as UTC.
+func parseICalTime(value string, params map[string]string) (time.Time, error) {
+	if params["VALUE"] == "DATE" || len(value) == len(icalDate) {
+		return time.Parse(icalDate, value)
+	}
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse(icalDateTime, strings.TrimSuffix(value, "Z"))
+	}
+	loc := time.UTC
+	if tz := params["TZID"]; tz != "" {
+		if l, err := time.LoadLocation(tz); err == nil {
+			loc = l
+		}
+	}
+	return time.ParseInLocation(icalDateTime, value, loc)
+}
+
+// parseICalDuration parses durations such as P1D, P2W or PT36H.
+func parseICalDuration(s string) (time.Duration, error) {
+	s = strings.TrimPrefix(s, "+")
+	if !strings.HasPrefix(s, "P") {
+		return 0, ErrInvalidICal
+	}
+	s = s[1:]
+	if s == "" || s == "T" {
+		return 0, ErrInvalidICal
+	}
+	var d time.Duration
+	inTime := false
+	num := ""
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			num += string(r)
+		case r == 'T':
+			inTime = true
+		default:
+			n, err := strconv.Atoi(num)
+			if err != nil {
+				return 0, ErrInvalidICal
+			}
+			num = ""
+			switch {
+			case r == 'W' && !inTime:
+				d += time.Duration(n) * 7 * 24 * time.Hour
+			case r == 'D' && !inTime:
+				d += time.Duration(n) * 24 * time.Hour
+			case r == 'H' && inTime:
+				d += time.Duration(n) * time.Hour
+			case r == 'M' && inTime:
+				d += time.Duration(n) * time.Minute
+			case r == 'S' && inTime:
+				d += time.Duration(n) * time.Second
+			default:
+				return 0, ErrInvalidICal
+			}
+		}
+	}
+	if num != "" {
+		return 0, ErrInvalidICal
+	}
+	return d, nil
+}
+
+// dateOf truncates t to midnight UTC of its calendar day.
+func dateOf(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
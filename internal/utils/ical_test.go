@@ -0,0 +1,214 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata" // TZID cases must not depend on the host's zoneinfo
+	"unicode/utf8"
+)
+
+func day(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// vcal wraps CRLF-joined lines in a VCALENDAR.
+func vcal(lines ...string) string {
+	all := append([]string{"BEGIN:VCALENDAR", "VERSION:2.0"}, lines...)
+	all = append(all, "END:VCALENDAR")
+	return strings.Join(all, "\r\n") + "\r\n"
+}
+
+func TestParseICal(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		ics        string
+		start, end string
+		summary    string
+		cancelled  bool
+	}{
+		{
+			name: "all-day",
+			ics: vcal("BEGIN:VEVENT", "UID:a", "DTSTART;VALUE=DATE:20250301", "DTEND;VALUE=DATE:20250304",
+				"SUMMARY:Airbnb (Not available)", "END:VEVENT"),
+			start: "2025-03-01", end: "2025-03-04", summary: "Airbnb (Not available)",
+		},
+		{
+			name: "folded lines",
+			ics: vcal("BEGIN:VEVENT", "UID:a", "DTSTART;VALUE=DATE:20250301", "DTEND;VALUE=DATE:20250302",
+				"SUMMARY:Reserved for a lo", " ng stay\\, with", "\t escapes", "END:VEVENT"),
+			start: "2025-03-01", end: "2025-03-02", summary: "Reserved for a long stay, with escapes",
+		},
+		{
+			name: "bare LF line endings",
+			ics: strings.ReplaceAll(vcal("BEGIN:VEVENT", "UID:a", "DTSTART;VALUE=DATE:20250301",
+				"DTEND;VALUE=DATE:20250302", "END:VEVENT"), "\r\n", "\n"),
+			start: "2025-03-01", end: "2025-03-02",
+		},
+		{
+			name: "UTC times touch every day they span",
+			ics: vcal("BEGIN:VEVENT", "UID:a", "DTSTART:20250301T140000Z", "DTEND:20250303T100000Z",
+				"END:VEVENT"),
+			start: "2025-03-01", end: "2025-03-03",
+		},
+		{
+			name: "TZID times are read in their zone",
+			// 23:30 in New York on 1 March is already 2 March in UTC
+			ics: vcal("BEGIN:VEVENT", "UID:a", `DTSTART;TZID="America/New_York":20250301T233000`,
+				"DTEND;TZID=America/New_York:20250302T233000", "END:VEVENT"),
+			start: "2025-03-01", end: "2025-03-02",
+		},
+		{
+			name: "unknown TZID is read as UTC",
+			ics: vcal("BEGIN:VEVENT", "UID:a", "DTSTART;TZID=Mars/Olympus:20250301T233000",
+				"DTEND;TZID=Mars/Olympus:20250303T010000", "END:VEVENT"),
+			start: "2025-03-01", end: "2025-03-03",
+		},
+		{
+			name: "floating times",
+			ics: vcal("BEGIN:VEVENT", "UID:a", "DTSTART:20250301T150000", "DTEND:20250302T110000",
+				"END:VEVENT"),
+			start: "2025-03-01", end: "2025-03-02",
+		},
+		{
+			name:  "DURATION without DTEND",
+			ics:   vcal("BEGIN:VEVENT", "UID:a", "DTSTART;VALUE=DATE:20250301", "DURATION:P1W", "END:VEVENT"),
+			start: "2025-03-01", end: "2025-03-08",
+		},
+		{
+			name: "timed DURATION ends on the day it reaches",
+			ics: vcal("BEGIN:VEVENT", "UID:a", "DTSTART:20250301T120000Z", "DURATION:PT36H",
+				"END:VEVENT"),
+			start: "2025-03-01", end: "2025-03-03",
+		},
+		{
+			name:  "no DTEND or DURATION lasts a day",
+			ics:   vcal("BEGIN:VEVENT", "UID:a", "DTSTART;VALUE=DATE:20250301", "END:VEVENT"),
+			start: "2025-03-01", end: "2025-03-02",
+		},
+		{
+			name: "VALARM properties are ignored",
+			ics: vcal("BEGIN:VEVENT", "UID:a", "DTSTART;VALUE=DATE:20250301", "SUMMARY:Blocked",
+				"BEGIN:VALARM", "TRIGGER:-PT15M", "DURATION:PT5M", "SUMMARY:Alarm", "STATUS:CANCELLED",
+				"END:VALARM", "DTEND;VALUE=DATE:20250305", "END:VEVENT"),
+			start: "2025-03-01", end: "2025-03-05", summary: "Blocked",
+		},
+		{
+			name: "cancelled",
+			ics: vcal("BEGIN:VEVENT", "UID:a", "DTSTART;VALUE=DATE:20250301", "DTEND;VALUE=DATE:20250302",
+				"STATUS:CANCELLED", "END:VEVENT"),
+			start: "2025-03-01", end: "2025-03-02", cancelled: true,
+		},
+	} {
+		events, err := ParseICal(strings.NewReader(tc.ics))
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if len(events) != 1 {
+			t.Errorf("%s: got %d events; want 1", tc.name, len(events))
+			continue
+		}
+		e := events[0]
+		if !e.Start.Equal(day(tc.start)) || !e.End.Equal(day(tc.end)) {
+			t.Errorf("%s: got %s to %s; want %s to %s", tc.name,
+				e.Start.Format("2006-01-02"), e.End.Format("2006-01-02"), tc.start, tc.end)
+		}
+		if e.Summary != tc.summary || e.Cancelled != tc.cancelled {
+			t.Errorf("%s: got summary %q cancelled %v; want %q %v", tc.name, e.Summary, e.Cancelled, tc.summary, tc.cancelled)
+		}
+	}
+}
+
+func TestParseICalRejects(t *testing.T) {
+	for name, ics := range map[string]string{
+		"no VCALENDAR":      "BEGIN:VEVENT\r\nDTSTART;VALUE=DATE:20250301\r\nEND:VEVENT\r\n",
+		"no DTSTART":        vcal("BEGIN:VEVENT", "UID:a", "END:VEVENT"),
+		"bad DTSTART":       vcal("BEGIN:VEVENT", "DTSTART:2025-03-01", "END:VEVENT"),
+		"bad DURATION":      vcal("BEGIN:VEVENT", "DTSTART;VALUE=DATE:20250301", "DURATION:3 days", "END:VEVENT"),
+		"unterminated":      vcal("BEGIN:VEVENT", "DTSTART;VALUE=DATE:20250301"),
+		"unterminated nest": vcal("BEGIN:VEVENT", "DTSTART;VALUE=DATE:20250301", "BEGIN:VALARM", "END:VEVENT"),
+	} {
+		if _, err := ParseICal(strings.NewReader(ics)); !errors.Is(err, ErrInvalidICal) {
+			t.Errorf("%s: err = %v; want ErrInvalidICal", name, err)
+		}
+	}
+}
+
+func TestParseICalDuration(t *testing.T) {
+	for in, want := range map[string]time.Duration{
+		"P1D":       24 * time.Hour,
+		"+P2W":      14 * 24 * time.Hour,
+		"PT36H":     36 * time.Hour,
+		"P1DT2H30M": 26*time.Hour + 30*time.Minute,
+		"PT90S":     90 * time.Second,
+	} {
+		if got, err := parseICalDuration(in); err != nil || got != want {
+			t.Errorf("parseICalDuration(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "1D", "P", "PT", "P1", "P1H", "PT1D", "P1X", "PD", "-P1D"} {
+		if got, err := parseICalDuration(in); err == nil {
+			t.Errorf("parseICalDuration(%q) = %v; want an error", in, got)
+		}
+	}
+}
+
+func TestWriteFoldedKeepsCharactersWhole(t *testing.T) {
+	for _, s := range []string{
+		"SUMMARY:" + strings.Repeat("a", 200),
+		"SUMMARY:" + strings.Repeat("é", 100), // 2 octets
+		"SUMMARY:" + strings.Repeat("🏠", 50),  // 4 octets
+		"SUMMARY:a" + strings.Repeat("日", 70), // 3 octets, off by one from the limit
+	} {
+		var buf bytes.Buffer
+		w := bufio.NewWriter(&buf)
+		writeFolded(w, s)
+		w.Flush()
+
+		physical := strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n")
+		for i, l := range physical {
+			if len(l) > icalLineMax {
+				t.Errorf("line %d is %d octets; want at most %d", i, len(l), icalLineMax)
+			}
+			if !utf8.ValidString(l) {
+				t.Errorf("line %d splits a character: %q", i, l)
+			}
+			if i > 0 && l[0] != ' ' {
+				t.Errorf("continuation line %d does not start with a space", i)
+			}
+		}
+		lines, err := unfoldICal(&buf)
+		if err != nil || len(lines) != 1 || lines[0] != s {
+			t.Errorf("unfolding gave %q, %v; want %q", lines, err, s)
+		}
+	}
+}
+
+func TestWriteICalRoundTrip(t *testing.T) {
+	in := []ICalEvent{{
+		UID:     "b1@realestate-backend",
+		Summary: "Réservé; " + strings.Repeat("ñ", 60),
+		Start:   day("2025-03-01"),
+		End:     day("2025-03-04"),
+		Stamp:   time.Date(2025, 2, 1, 9, 30, 0, 0, time.UTC),
+	}}
+	var buf bytes.Buffer
+	if err := WriteICal(&buf, "-//test//EN", "Lekki flat, 2 bed", in); err != nil {
+		t.Fatal(err)
+	}
+	out, err := ParseICal(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 1 || out[0] != in[0] {
+		t.Errorf("round trip gave %+v; want %+v", out, in)
+	}
+}
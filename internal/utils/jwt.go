@@ -6,30 +6,41 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// GenerateJWT returns a signed token with subject=userID and claim role
-func GenerateJWT(userID string, role string, secret string, ttl time.Duration) (string, error) {
-	claims := jwt.RegisteredClaims{
-		Subject:   userID,
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
+// AccessClaims are the claims of an access token. SessionID is the session
+// family the token was issued for, checked on every request so logouts take
+// effect immediately.
+type AccessClaims struct {
+	Role      string `json:"role"`
+	SessionID string `json:"sid,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// GenerateAccessToken returns a signed token with subject=userID, claim role
+// and the session it belongs to.
+func GenerateAccessToken(userID, role, sessionID, secret string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := AccessClaims{
+		Role:      role,
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   userID,
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub":   claims.Subject,
-		"exp":   claims.ExpiresAt.Unix(),
-		"iat":   claims.IssuedAt.Unix(),
-		"role":  role,
-	})
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(secret))
 }
 
-func ParseJWT(tokenStr string, secret string) (*jwt.RegisteredClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenStr, &jwt.RegisteredClaims{}, func(token *jwt.Token) (interface{}, error) {
+// ParseAccessToken verifies an HS256 access token and returns its claims.
+func ParseAccessToken(tokenStr string, secret string) (*AccessClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenStr, &AccessClaims{}, func(token *jwt.Token) (interface{}, error) {
 		return []byte(secret), nil
-	})
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 	if err != nil {
 		return nil, err
 	}
-	if claims, ok := token.Claims.(*jwt.RegisteredClaims); ok && token.Valid {
+	if claims, ok := token.Claims.(*AccessClaims); ok && token.Valid {
 		return claims, nil
 	}
 	return nil, jwt.ErrTokenInvalidClaims
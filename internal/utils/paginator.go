@@ -1 +1,297 @@
 package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// PageError is a bad page, limit or cursor parameter.
+type PageError struct {
+	Param   string
+	Message string
+}
+
+func (e *PageError) Error() string { return e.Param + ": " + e.Message }
+
+// SortColumn is one column of a list's ORDER BY. The last column of a
+// paginated order must be unique, usually the id, so the order is total.
+type SortColumn struct {
+	Column string // as written in SQL, e.g. "properties.created_at"
+	Desc   bool
+}
+
+// Paginator pages a list either by page number (?page=&limit=) or by an
+// opaque keyset cursor (?cursor=&limit=) taken from a previous response's
+// next_cursor. Cursors don't skip or repeat rows when the list changes
+// between requests; pages are kept for clients that need to jump around.
+//
+// Use it as: Order, then Scope on the query, then Trim on the rows found.
+type Paginator struct {
+	Limit int
+	// Page is 1-based and 0 when paging by cursor.
+	Page int
+	// Total, when the caller counts rows, is included in the response.
+	Total *int64
+	// NextCursor continues after the last row returned; empty on the last
+	// page or when the order can't be keyset paged.
+	NextCursor string
+	HasMore    bool
+
+	url    url.URL
+	cursor string
+	after  []interface{}
+	keys   []SortColumn
+	err    error
+}
+
+// NewPaginator reads page, limit and cursor from u's query. defaultLimit
+// applies when limit is missing; limits above MaxPageLimit are refused.
+func NewPaginator(u *url.URL, defaultLimit int) (*Paginator, error) {
+	q := u.Query()
+	p := &Paginator{Limit: defaultLimit, Page: 1, url: *u}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > MaxPageLimit {
+			return nil, &PageError{"limit", fmt.Sprintf("must be between 1 and %d", MaxPageLimit)}
+		}
+		p.Limit = n
+	}
+	if p.cursor = q.Get("cursor"); p.cursor != "" {
+		if q.Get("page") != "" {
+			return nil, &PageError{"cursor", "can't be combined with page"}
+		}
+		p.Page = 0
+		return p, nil
+	}
+	if v := q.Get("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, &PageError{"page", "must be a whole number of 1 or more"}
+		}
+		p.Page = n
+	}
+	return p, nil
+}
+
+// Order sets the list order Scope applies. With no columns the caller
+// orders the query itself, and only page numbers work.
+func (p *Paginator) Order(keys ...SortColumn) error {
+	p.keys = keys
+	if p.cursor == "" {
+		return nil
+	}
+	if len(keys) == 0 {
+		return &PageError{"cursor", "not available for this sort; use page"}
+	}
+	after, err := decodeCursor(p.cursor, p.signature())
+	if err != nil || len(after) != len(keys) {
+		return &PageError{"cursor", "is invalid or belongs to a different sort"}
+	}
+	p.after = after
+	return nil
+}
+
+// Scope orders the query, skips to the page and fetches one row more than
+// the limit so Trim can tell whether another page follows.
+func (p *Paginator) Scope(db *gorm.DB) *gorm.DB {
+	for _, k := range p.keys {
+		dir := " ASC"
+		if k.Desc {
+			dir = " DESC"
+		}
+		db = db.Order(k.Column + dir)
+	}
+	if p.after != nil {
+		cond, vars := p.keysetCondition()
+		db = db.Where(cond, vars...)
+	} else if p.Page > 1 {
+		db = db.Offset((p.Page - 1) * p.Limit)
+	}
+	return db.Limit(p.Limit + 1)
+}
+
+// keysetCondition selects rows after p.after in key order:
+// (a > ?) OR (a = ? AND b > ?) OR ..., flipping > for descending columns.
+func (p *Paginator) keysetCondition() (string, []interface{}) {
+	var ors []string
+	var vars []interface{}
+	for i, k := range p.keys {
+		var ands []string
+		for j := 0; j < i; j++ {
+			ands = append(ands, p.keys[j].Column+" = ?")
+			vars = append(vars, p.after[j])
+		}
+		op := " > ?"
+		if k.Desc {
+			op = " < ?"
+		}
+		ands = append(ands, k.Column+op)
+		vars = append(vars, p.after[i])
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+	}
+	return "(" + strings.Join(ors, " OR ") + ")", vars
+}
+
+// Trim takes the number of rows found and returns how many to keep. key
+// returns row i's values for the Order columns, in order. A key that can't
+// go in a cursor is reported by Err.
+func (p *Paginator) Trim(found int, key func(i int) []interface{}) int {
+	if found <= p.Limit {
+		return found
+	}
+	p.HasMore = true
+	if len(p.keys) > 0 {
+		p.NextCursor, p.err = encodeCursor(p.signature(), key(p.Limit-1))
+	}
+	return p.Limit
+}
+
+// Err reports why Trim couldn't make the next cursor, such as an Order
+// column of a type cursors don't support.
+func (p *Paginator) Err() error { return p.err }
+
+// Meta is the paging fields to merge into a list response.
+func (p *Paginator) Meta() map[string]interface{} {
+	m := map[string]interface{}{
+		"limit":       p.Limit,
+		"has_more":    p.HasMore,
+		"next_cursor": p.NextCursor,
+	}
+	if p.Page > 0 {
+		m["page"] = p.Page
+	}
+	if p.Total != nil {
+		m["total"] = *p.Total
+	}
+	return m
+}
+
+// Link is an RFC 8288 Link header value with next, prev and first links,
+// relative to the request URL.
+func (p *Paginator) Link() string {
+	var links []string
+	add := func(rel string, set map[string]string) {
+		u := p.url
+		q := u.Query()
+		q.Del("page")
+		q.Del("cursor")
+		for k, v := range set {
+			q.Set(k, v)
+		}
+		u.RawQuery = q.Encode()
+		u.Scheme, u.Host = "", ""
+		links = append(links, fmt.Sprintf("<%s>; rel=%q", u.String(), rel))
+	}
+	if p.HasMore {
+		if p.NextCursor != "" {
+			add("next", map[string]string{"cursor": p.NextCursor})
+		} else {
+			add("next", map[string]string{"page": strconv.Itoa(p.Page + 1)})
+		}
+	}
+	if p.Page > 1 {
+		add("prev", map[string]string{"page": strconv.Itoa(p.Page - 1)})
+	}
+	if p.Page != 1 {
+		add("first", nil)
+	}
+	return strings.Join(links, ", ")
+}
+
+// signature ties a cursor to the order it was made for.
+func (p *Paginator) signature() string {
+	parts := make([]string, len(p.keys))
+	for i, k := range p.keys {
+		parts[i] = k.Column
+		if k.Desc {
+			parts[i] += " desc"
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// cursorValue is a typed key value, so it binds as the column's type again.
+type cursorValue struct {
+	T string `json:"t"`
+	V string `json:"v"`
+}
+
+type cursorBody struct {
+	Sort   string        `json:"s"`
+	Values []cursorValue `json:"v"`
+}
+
+func encodeCursor(sort string, values []interface{}) (string, error) {
+	body := cursorBody{Sort: sort, Values: make([]cursorValue, len(values))}
+	for i, v := range values {
+		switch v := v.(type) {
+		case time.Time:
+			body.Values[i] = cursorValue{"time", v.UTC().Format(time.RFC3339Nano)}
+		case uuid.UUID:
+			body.Values[i] = cursorValue{"uuid", v.String()}
+		case int:
+			body.Values[i] = cursorValue{"int", strconv.Itoa(v)}
+		case int64:
+			body.Values[i] = cursorValue{"int", strconv.FormatInt(v, 10)}
+		case float64:
+			body.Values[i] = cursorValue{"float", strconv.FormatFloat(v, 'g', -1, 64)}
+		case string:
+			body.Values[i] = cursorValue{"string", v}
+		default:
+			return "", fmt.Errorf("paginator: unsupported cursor value %T", v)
+		}
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func decodeCursor(s, sort string) ([]interface{}, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	var body cursorBody
+	if err := json.Unmarshal(b, &body); err != nil {
+		return nil, err
+	}
+	if body.Sort != sort {
+		return nil, fmt.Errorf("cursor is for %q", body.Sort)
+	}
+	values := make([]interface{}, len(body.Values))
+	for i, cv := range body.Values {
+		switch cv.T {
+		case "time":
+			values[i], err = time.Parse(time.RFC3339Nano, cv.V)
+		case "uuid":
+			values[i], err = uuid.Parse(cv.V)
+		case "int":
+			values[i], err = strconv.ParseInt(cv.V, 10, 64)
+		case "float":
+			values[i], err = strconv.ParseFloat(cv.V, 64)
+		case "string":
+			values[i] = cv.V
+		default:
+			err = fmt.Errorf("unknown cursor value type %q", cv.T)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	id := uuid.MustParse("0d6d8c4a-5a53-4c1e-9d55-27b4c5f1a0e2")
+	lagos := time.FixedZone("WAT", 3600)
+	for _, values := range [][]interface{}{
+		{time.Date(2026, 3, 1, 9, 30, 0, 123456789, lagos), id},
+		{int64(2500000), id},
+		{4, id},
+		{0.25, id},
+		{"Lekki, Phase 1", id},
+	} {
+		s, err := encodeCursor("a,b desc", values)
+		if err != nil {
+			t.Fatalf("encodeCursor(%v): %v", values, err)
+		}
+		got, err := decodeCursor(s, "a,b desc")
+		if err != nil {
+			t.Fatalf("decodeCursor(%v): %v", values, err)
+		}
+		want := append([]interface{}(nil), values...)
+		switch v := want[0].(type) {
+		case time.Time:
+			// times come back in UTC, to the nanosecond
+			want[0] = v.UTC()
+		case int:
+			// ints come back as the int64 Postgres binds them as
+			want[0] = int64(v)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("round trip of %v = %v", values, got)
+		}
+	}
+}
+
+func TestEncodeCursorRejectsUnsupportedValues(t *testing.T) {
+	if s, err := encodeCursor("a", []interface{}{true}); err == nil {
+		t.Errorf("encodeCursor(bool) = %q; want an error", s)
+	}
+}
+
+func TestDecodeCursorRejects(t *testing.T) {
+	good, err := encodeCursor("a desc,id desc", []interface{}{int64(1), uuid.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, tc := range map[string]struct{ cursor, sort string }{
+		"other sort":   {good, "a,id"},
+		"not base64":   {"!!!", "a desc,id desc"},
+		"not json":     {rawCursor("not json"), "a desc,id desc"},
+		"unknown type": {rawCursor(`{"s":"a","v":[{"t":"bool","v":"true"}]}`), "a"},
+		"bad value":    {rawCursor(`{"s":"a","v":[{"t":"uuid","v":"nope"}]}`), "a"},
+	} {
+		if v, err := decodeCursor(tc.cursor, tc.sort); err == nil {
+			t.Errorf("%s: decodeCursor = %v; want an error", name, v)
+		}
+	}
+}
+
+func rawCursor(body string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(body))
+}
+
+func TestPaginatorCursorPages(t *testing.T) {
+	keys := []SortColumn{{Column: "created_at", Desc: true}, {Column: "id", Desc: true}}
+	first := mustPaginator(t, "/things?limit=2")
+	if err := first.Order(keys...); err != nil {
+		t.Fatal(err)
+	}
+	rows := []interface{}{time.Date(2026, 1, 3, 0, 0, 0, 0, time.UTC), time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	ids := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+	if n := first.Trim(len(rows), func(i int) []interface{} { return []interface{}{rows[i], ids[i]} }); n != 2 {
+		t.Fatalf("Trim kept %d rows; want 2", n)
+	}
+	if !first.HasMore || first.NextCursor == "" || first.Err() != nil {
+		t.Fatalf("first page: has_more %v cursor %q err %v", first.HasMore, first.NextCursor, first.Err())
+	}
+
+	next := mustPaginator(t, "/things?limit=2&cursor="+first.NextCursor)
+	if err := next.Order(keys...); err != nil {
+		t.Fatal(err)
+	}
+	cond, vars := next.keysetCondition()
+	if cond != "((created_at < ?) OR (created_at = ? AND id < ?))" {
+		t.Errorf("keyset condition = %s", cond)
+	}
+	if want := []interface{}{rows[1], rows[1], ids[1]}; !reflect.DeepEqual(vars, want) {
+		t.Errorf("keyset values = %v; want %v", vars, want)
+	}
+
+	// a cursor only works for the order it was made for
+	other := mustPaginator(t, "/things?cursor="+first.NextCursor)
+	var pe *PageError
+	if err := other.Order(SortColumn{Column: "price"}, SortColumn{Column: "id"}); !errors.As(err, &pe) || pe.Param != "cursor" {
+		t.Errorf("cursor for another sort: err = %v; want a cursor PageError", err)
+	}
+}
+
+func TestPaginatorReportsUnencodableKeys(t *testing.T) {
+	p := mustPaginator(t, "/things?limit=1")
+	if err := p.Order(SortColumn{Column: "flag"}, SortColumn{Column: "id"}); err != nil {
+		t.Fatal(err)
+	}
+	if n := p.Trim(2, func(i int) []interface{} { return []interface{}{true, i} }); n != 1 {
+		t.Fatalf("Trim kept %d rows; want 1", n)
+	}
+	if p.Err() == nil || p.NextCursor != "" {
+		t.Errorf("Err = %v, cursor %q; want an error and no cursor", p.Err(), p.NextCursor)
+	}
+}
+
+func mustPaginator(t *testing.T, rawURL string) *Paginator {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewPaginator(u, DefaultPageLimit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
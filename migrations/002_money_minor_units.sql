@@ -0,0 +1,94 @@
+-- Store money as integer minor units (kobo, cents) instead of NUMERIC major
+-- units. Each amount moves to a new *_minor BIGINT column next to a currency
+-- column, and the old column is dropped once copied.
+--
+-- Run this before starting a build that includes the Money type: AutoMigrate
+-- only adds the new columns, it doesn't carry the old amounts across.
+
+BEGIN;
+
+-- minor units per major unit for a currency (see models.CurrencyExponent)
+CREATE FUNCTION pg_temp.minor_factor(code TEXT) RETURNS NUMERIC AS $$
+  SELECT CASE upper(coalesce(code, 'NGN'))
+    WHEN 'JPY' THEN 1 WHEN 'KRW' THEN 1 WHEN 'XOF' THEN 1 WHEN 'XAF' THEN 1
+    WHEN 'KWD' THEN 1000 WHEN 'BHD' THEN 1000
+    ELSE 100
+  END
+$$ LANGUAGE SQL IMMUTABLE;
+
+-- properties
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS price_minor BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS cleaning_fee_minor BIGINT DEFAULT 0;
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS caution_deposit_minor BIGINT DEFAULT 0;
+UPDATE properties SET currency = 'NGN' WHERE currency IS NULL OR currency = '';
+UPDATE properties SET price_minor = round(price * pg_temp.minor_factor(currency));
+ALTER TABLE properties DROP COLUMN price;
+
+DO $$
+BEGIN
+  IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'properties' AND column_name = 'cleaning_fee') THEN
+    UPDATE properties SET
+      cleaning_fee_minor = round(coalesce(cleaning_fee, 0) * pg_temp.minor_factor(currency)),
+      caution_deposit_minor = round(coalesce(caution_deposit, 0) * pg_temp.minor_factor(currency));
+    ALTER TABLE properties DROP COLUMN cleaning_fee, DROP COLUMN caution_deposit;
+  END IF;
+END $$;
+
+-- bookings are charged in their property's currency
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS total_amount_minor BIGINT;
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS currency TEXT DEFAULT 'NGN';
+UPDATE bookings b SET currency = p.currency FROM properties p WHERE p.id = b.property_id;
+UPDATE bookings SET total_amount_minor = round(coalesce(total_amount, 0) * pg_temp.minor_factor(currency));
+ALTER TABLE bookings DROP COLUMN total_amount;
+
+-- tables created by AutoMigrate, present once their features have shipped
+DO $$
+BEGIN
+  IF to_regclass('property_rates') IS NOT NULL THEN
+    ALTER TABLE property_rates ADD COLUMN IF NOT EXISTS nightly_price_minor BIGINT;
+    ALTER TABLE property_rates ADD COLUMN IF NOT EXISTS currency TEXT;
+    UPDATE property_rates r SET currency = p.currency FROM properties p WHERE p.id = r.property_id;
+    UPDATE property_rates SET nightly_price_minor = round(nightly_price * pg_temp.minor_factor(currency));
+    ALTER TABLE property_rates DROP COLUMN nightly_price;
+  END IF;
+
+  IF to_regclass('booking_line_items') IS NOT NULL THEN
+    ALTER TABLE booking_line_items ADD COLUMN IF NOT EXISTS amount_minor BIGINT;
+    ALTER TABLE booking_line_items ADD COLUMN IF NOT EXISTS currency TEXT;
+    UPDATE booking_line_items l SET currency = b.currency FROM bookings b WHERE b.id = l.booking_id;
+    UPDATE booking_line_items SET amount_minor = round(amount * pg_temp.minor_factor(currency));
+    ALTER TABLE booking_line_items DROP COLUMN amount;
+  END IF;
+
+  IF to_regclass('payments') IS NOT NULL THEN
+    ALTER TABLE payments ADD COLUMN IF NOT EXISTS amount_minor BIGINT;
+    ALTER TABLE payments ADD COLUMN IF NOT EXISTS refunded_amount_minor BIGINT;
+    UPDATE payments SET
+      amount_minor = round(coalesce(amount, 0) * pg_temp.minor_factor(currency)),
+      refunded_amount_minor = round(coalesce(refunded_amount, 0) * pg_temp.minor_factor(currency));
+    ALTER TABLE payments DROP COLUMN amount, DROP COLUMN refunded_amount;
+  END IF;
+
+  IF to_regclass('booking_cancellations') IS NOT NULL THEN
+    ALTER TABLE booking_cancellations ADD COLUMN IF NOT EXISTS refund_amount_minor BIGINT;
+    ALTER TABLE booking_cancellations ADD COLUMN IF NOT EXISTS currency TEXT;
+    UPDATE booking_cancellations c SET currency = b.currency FROM bookings b WHERE b.id = c.booking_id;
+    UPDATE booking_cancellations SET refund_amount_minor = round(coalesce(refund_amount, 0) * pg_temp.minor_factor(currency));
+    ALTER TABLE booking_cancellations DROP COLUMN refund_amount;
+  END IF;
+
+  IF to_regclass('booking_modifications') IS NOT NULL THEN
+    ALTER TABLE booking_modifications ADD COLUMN IF NOT EXISTS old_total_minor BIGINT;
+    ALTER TABLE booking_modifications ADD COLUMN IF NOT EXISTS new_total_minor BIGINT;
+    ALTER TABLE booking_modifications ADD COLUMN IF NOT EXISTS difference_minor BIGINT;
+    ALTER TABLE booking_modifications ADD COLUMN IF NOT EXISTS currency TEXT;
+    UPDATE booking_modifications m SET currency = b.currency FROM bookings b WHERE b.id = m.booking_id;
+    UPDATE booking_modifications SET
+      old_total_minor = round(old_total * pg_temp.minor_factor(currency)),
+      new_total_minor = round(new_total * pg_temp.minor_factor(currency)),
+      difference_minor = round(difference * pg_temp.minor_factor(currency));
+    ALTER TABLE booking_modifications DROP COLUMN old_total, DROP COLUMN new_total, DROP COLUMN difference;
+  END IF;
+END $$;
+
+COMMIT;
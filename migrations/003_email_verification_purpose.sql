@@ -0,0 +1,14 @@
+-- One-time codes are now scoped by purpose (email verification, password
+-- reset), so an email may hold one live code per purpose. Replace the unique
+-- index on email alone with one on (email, purpose).
+
+BEGIN;
+
+ALTER TABLE email_verifications ADD COLUMN IF NOT EXISTS purpose TEXT DEFAULT 'verify_email';
+ALTER TABLE email_verifications ADD COLUMN IF NOT EXISTS attempts BIGINT DEFAULT 0;
+UPDATE email_verifications SET purpose = 'verify_email' WHERE purpose IS NULL;
+
+DROP INDEX IF EXISTS idx_email_verifications_email;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_email_verifications_email_purpose ON email_verifications (email, purpose);
+
+COMMIT;
@@ -0,0 +1,20 @@
+-- Full-text search over listings (the q parameter of GET /properties).
+-- search_vector is kept up to date by Postgres; AutoMigrate doesn't know
+-- about it, so run this before starting a build with search.
+
+BEGIN;
+
+-- trigram similarity, for typo-tolerant area matching
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS search_vector tsvector
+  GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(area, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(address, '')), 'B') ||
+    setweight(to_tsvector('english', coalesce(description, '')), 'C')
+  ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_properties_search_vector ON properties USING GIN (search_vector);
+
+COMMIT;